@@ -0,0 +1,306 @@
+package way
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessLogFormat selects the line format written by AccessLog.
+type AccessLogFormat int
+
+const (
+	// AccessLogJSON writes one JSON object per request with the configured fields.
+	AccessLogJSON AccessLogFormat = iota
+	// AccessLogCommon writes the Apache Common Log Format.
+	AccessLogCommon
+	// AccessLogCombined writes the Apache Combined Log Format, which adds the referer and user agent.
+	AccessLogCombined
+)
+
+// Access log fields available to the JSON format.
+const (
+	AccessLogFieldTime       = "time"
+	AccessLogFieldMethod     = "method"
+	AccessLogFieldPath       = "path"
+	AccessLogFieldQuery      = "query"
+	AccessLogFieldProto      = "proto"
+	AccessLogFieldHost       = "host"
+	AccessLogFieldStatus     = "status"
+	AccessLogFieldBytes      = "bytes"
+	AccessLogFieldDuration   = "duration_ms"
+	AccessLogFieldRemoteAddr = "remote_addr"
+	AccessLogFieldUserAgent  = "user_agent"
+	AccessLogFieldReferer    = "referer"
+	AccessLogFieldRequestID  = "request_id"
+	AccessLogFieldRoute      = "route"
+)
+
+// DefaultAccessLogFields are the fields written by the JSON format when AccessLogConfig.Fields is empty.
+var DefaultAccessLogFields = []string{
+	AccessLogFieldTime,
+	AccessLogFieldMethod,
+	AccessLogFieldPath,
+	AccessLogFieldStatus,
+	AccessLogFieldBytes,
+	AccessLogFieldDuration,
+	AccessLogFieldRemoteAddr,
+	AccessLogFieldUserAgent,
+	AccessLogFieldRequestID,
+}
+
+// accessLogRedacted replaces redacted values in logged paths and queries.
+const accessLogRedacted = "REDACTED"
+
+// AccessLogConfig configures the AccessLog middleware.
+// Format selects the line format, JSON by default.
+// Output receives one line per request, os.Stdout when nil. Writes are serialized.
+// Fields lists the fields written by the JSON format, DefaultAccessLogFields when empty.
+// RedactPathParams lists route parameters whose values are replaced with {name} in the logged path,
+// e.g. /users/{id} is logged instead of /users/42.
+// RedactQueryParams lists query parameters whose values are replaced with REDACTED.
+// Skip, when set, disables logging for requests it returns true for.
+type AccessLogConfig struct {
+	Format            AccessLogFormat
+	Output            io.Writer
+	Fields            []string
+	RedactPathParams  []string
+	RedactQueryParams []string
+	Skip              func(*Context) bool
+}
+
+// AccessLog returns middleware that writes an access log line for every request after the handler returns.
+// Register it with Use before Recover so that recovered panics are logged with their 500 status.
+func AccessLog(config AccessLogConfig) MiddlewareFunc {
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if len(config.Fields) == 0 {
+		config.Fields = DefaultAccessLogFields
+	}
+	var mu sync.Mutex
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			start := time.Now()
+			next(c)
+			if config.Skip != nil && config.Skip(c) {
+				return
+			}
+			var line []byte
+			switch config.Format {
+			case AccessLogCommon:
+				line = config.commonLine(c, start, false)
+			case AccessLogCombined:
+				line = config.commonLine(c, start, true)
+			default:
+				var err error
+				if line, err = config.jsonLine(c, start); err != nil {
+					c.Slog().Error("failed to encode access log entry", "error", err)
+					return
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := config.Output.Write(line); err != nil {
+				c.Slog().Error("failed to write access log entry", "error", err)
+			}
+		}
+	}
+}
+
+// jsonLine formats the configured fields as a JSON object followed by a newline.
+func (config AccessLogConfig) jsonLine(c *Context, start time.Time) ([]byte, error) {
+	entry := make(map[string]interface{}, len(config.Fields))
+	for _, field := range config.Fields {
+		switch field {
+		case AccessLogFieldTime:
+			entry[field] = start.UTC().Format(time.RFC3339Nano)
+		case AccessLogFieldMethod:
+			entry[field] = c.Request.Method
+		case AccessLogFieldPath:
+			entry[field] = config.path(c)
+		case AccessLogFieldQuery:
+			entry[field] = config.query(c)
+		case AccessLogFieldProto:
+			entry[field] = c.Request.Proto
+		case AccessLogFieldHost:
+			entry[field] = c.Host()
+		case AccessLogFieldStatus:
+			entry[field] = accessLogStatus(c)
+		case AccessLogFieldBytes:
+			entry[field] = c.BytesWritten()
+		case AccessLogFieldDuration:
+			entry[field] = float64(time.Since(start).Microseconds()) / 1000
+		case AccessLogFieldRemoteAddr:
+			entry[field] = c.ClientIP()
+		case AccessLogFieldUserAgent:
+			entry[field] = c.Request.UserAgent()
+		case AccessLogFieldReferer:
+			entry[field] = c.Request.Referer()
+		case AccessLogFieldRequestID:
+			entry[field] = c.RequestID()
+		case AccessLogFieldRoute:
+			entry[field] = RouteTemplate(c.Request)
+		}
+	}
+	var line bytes.Buffer
+	if err := json.NewEncoder(&line).Encode(entry); err != nil {
+		return nil, err
+	}
+	return line.Bytes(), nil
+}
+
+// commonLine formats the request in the Apache Common Log Format, or the Combined Log Format when combined is set.
+func (config AccessLogConfig) commonLine(c *Context, start time.Time, combined bool) []byte {
+	user := "-"
+	if name, _, ok := c.Request.BasicAuth(); ok && name != "" {
+		user = name
+	}
+	size := "-"
+	if n := c.BytesWritten(); n > 0 {
+		size = strconv.FormatInt(n, 10)
+	}
+	target := config.path(c)
+	if query := config.query(c); query != "" {
+		target += "?" + query
+	}
+	var line strings.Builder
+	line.WriteString(c.ClientIP())
+	line.WriteString(" - ")
+	line.WriteString(user)
+	line.WriteString(" [")
+	line.WriteString(start.Format("02/Jan/2006:15:04:05 -0700"))
+	line.WriteString("] ")
+	line.WriteString(strconv.Quote(c.Request.Method + " " + target + " " + c.Request.Proto))
+	line.WriteString(" ")
+	line.WriteString(strconv.Itoa(accessLogStatus(c)))
+	line.WriteString(" ")
+	line.WriteString(size)
+	if combined {
+		line.WriteString(" ")
+		line.WriteString(strconv.Quote(c.Request.Referer()))
+		line.WriteString(" ")
+		line.WriteString(strconv.Quote(c.Request.UserAgent()))
+	}
+	line.WriteString("\n")
+	return []byte(line.String())
+}
+
+// path returns the request path with redacted route parameters replaced by their names.
+// Parameters are replaced at their positions in the route's path template, so that other
+// segments with the same value are kept.
+func (config AccessLogConfig) path(c *Context) string {
+	path := c.Request.URL.Path
+	if len(config.RedactPathParams) == 0 {
+		return path
+	}
+	template := RouteTemplate(c.Request)
+	if template == "" {
+		return path
+	}
+	vars := mux.Vars(c.Request)
+	matched := expandPathTemplate(template, func(name string) string {
+		return vars[name]
+	})
+	if !strings.HasPrefix(path, matched) {
+		// The path does not match its template, so log the template, which hides every parameter.
+		return template
+	}
+	redacted := expandPathTemplate(template, func(name string) string {
+		for _, redact := range config.RedactPathParams {
+			if name == redact {
+				return "{" + name + "}"
+			}
+		}
+		return vars[name]
+	})
+	// Path prefix routes match more of the path than their template.
+	return redacted + path[len(matched):]
+}
+
+// expandPathTemplate replaces each variable of a route path template, such as {id} or
+// {id:[0-9]+}, with value(name).
+func expandPathTemplate(template string, value func(name string) string) string {
+	var b strings.Builder
+	depth, start := 0, 0
+	for i := 0; i < len(template); i++ {
+		switch template[i] {
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				name, _, _ := strings.Cut(template[start+1:i], ":")
+				b.WriteString(value(name))
+			}
+		default:
+			if depth == 0 {
+				b.WriteByte(template[i])
+			}
+		}
+	}
+	return b.String()
+}
+
+// query returns the raw query with redacted parameter values replaced.
+func (config AccessLogConfig) query(c *Context) string {
+	if c.Request.URL.RawQuery == "" || len(config.RedactQueryParams) == 0 {
+		return c.Request.URL.RawQuery
+	}
+	values := c.Request.URL.Query()
+	redacted := false
+	for _, name := range config.RedactQueryParams {
+		if _, ok := values[name]; ok {
+			values[name] = []string{accessLogRedacted}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return c.Request.URL.RawQuery
+	}
+	return values.Encode()
+}
+
+// accessLogStatus returns the response status, 200 when the handler wrote nothing.
+func accessLogStatus(c *Context) int {
+	if status := c.StatusCode(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
+
+// remoteHost returns the host part of the request's remote address.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RouteTemplate returns the gorilla/mux path template of the route matched for r, such as
+// /users/{id}, or an empty string when no route has been matched. It is used to label logs,
+// spans and metrics without the raw path.
+func RouteTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
@@ -0,0 +1,154 @@
+package way
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogJSONRecordsStatusAndBytes(t *testing.T) {
+	var out bytes.Buffer
+	w := New()
+	w.Use(AccessLog(AccessLogConfig{
+		Output:            &out,
+		Fields:            []string{AccessLogFieldMethod, AccessLogFieldPath, AccessLogFieldQuery, AccessLogFieldStatus, AccessLogFieldBytes, AccessLogFieldRoute},
+		RedactPathParams:  []string{"id"},
+		RedactQueryParams: []string{"token"},
+	}))
+	w.GET("/users/{id}", func(c *Context) {
+		c.String(http.StatusAccepted, "hello")
+	})
+
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42?token=secret&page=2", nil))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	want := map[string]interface{}{
+		"method": "GET",
+		"path":   "/users/{id}",
+		"query":  "page=2&token=REDACTED",
+		"status": float64(http.StatusAccepted),
+		"bytes":  float64(5),
+		"route":  "/users/{id}",
+	}
+	if len(entry) != len(want) {
+		t.Fatalf("entry = %v, want fields %v", entry, want)
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Fatalf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+}
+
+func TestAccessLogRedactsPathParamsByPosition(t *testing.T) {
+	var out bytes.Buffer
+	w := New()
+	w.Use(AccessLog(AccessLogConfig{
+		Output:           &out,
+		Fields:           []string{AccessLogFieldPath},
+		RedactPathParams: []string{"id", "name"},
+	}))
+	api := w.Group("/api")
+	api.GET("/users/{id:[0-9]+}/posts/{post}", func(c *Context) {})
+	w.HandleFunc("/files/{id}.{ext}", func(c *Context) {})
+	w.router.PathPrefix("/assets/{name}/").HandlerFunc(w.adaptHandler(func(c *Context) {}))
+
+	tests := map[string]string{
+		"/api/users/1/posts/1":  "/api/users/{id}/posts/1",
+		"/files/7.7":            "/files/{id}.7",
+		"/assets/1/app/1/1.css": "/assets/{name}/app/1/1.css",
+	}
+	for path, want := range tests {
+		out.Reset()
+		w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		var entry map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+			t.Fatalf("decode %q: %v", out.String(), err)
+		}
+		if entry["path"] != want {
+			t.Fatalf("path for %s = %v, want %s", path, entry["path"], want)
+		}
+	}
+}
+
+func TestAccessLogCombinedFormat(t *testing.T) {
+	var out bytes.Buffer
+	w := New()
+	w.Use(AccessLog(AccessLogConfig{Format: AccessLogCombined, Output: &out}))
+	w.GET("/empty", func(c *Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.SetBasicAuth("ada", "pw")
+	w.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	pattern := regexp.MustCompile(`^203\.0\.113\.7 - ada \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /empty HTTP/1\.1" 200 - "https://example\.com/" "curl/8\.0"\n$`)
+	if !pattern.MatchString(out.String()) {
+		t.Fatalf("line = %q", out.String())
+	}
+}
+
+func TestAccessLogSkip(t *testing.T) {
+	var out bytes.Buffer
+	w := New()
+	w.Use(AccessLog(AccessLogConfig{
+		Format: AccessLogCommon,
+		Output: &out,
+		Skip:   func(c *Context) bool { return c.Request.URL.Path == "/healthz" },
+	}))
+	w.GET("/healthz", func(c *Context) { c.Status(http.StatusNoContent) })
+
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if out.Len() != 0 {
+		t.Fatalf("output = %q, want nothing", out.String())
+	}
+}
+
+func TestContextRecordsStatusAndBytes(t *testing.T) {
+	w := New()
+	var status int
+	var written int64
+	w.GET("/copy", func(c *Context) {
+		if c.Written() || c.StatusCode() != 0 {
+			t.Errorf("response written before handler wrote it")
+		}
+		c.Response.WriteHeader(http.StatusCreated)
+		io.Copy(c.Response, strings.NewReader("copied body"))
+		status, written = c.StatusCode(), c.BytesWritten()
+	})
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/copy", nil))
+
+	if status != http.StatusCreated || written != int64(len("copied body")) {
+		t.Fatalf("status, bytes = %d, %d", status, written)
+	}
+	if rec.Body.String() != "copied body" {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
+
+func TestResponseWriterFlushAndHijackSupport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := newResponseWriter(rec)
+
+	if err := http.NewResponseController(writer).Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if !rec.Flushed || !writer.written {
+		t.Fatal("Flush() did not flush and write the header")
+	}
+	if _, _, err := writer.Hijack(); err == nil {
+		t.Fatal("Hijack() error = nil for a recorder that cannot hijack")
+	}
+}
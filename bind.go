@@ -0,0 +1,344 @@
+package way
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// DefaultMaxBodyBytes is the default limit for request bodies read by the bind helpers.
+	DefaultMaxBodyBytes int64 = 10 << 20
+	// DefaultMaxMultipartMemory is the default number of bytes of a multipart form kept in memory.
+	DefaultMaxMultipartMemory int64 = 32 << 20
+)
+
+// BindOptions configures the request binding helpers.
+// MaxBodyBytes limits the size of request bodies, DefaultMaxBodyBytes when zero.
+// MaxMultipartMemory is the number of bytes of multipart form data kept in memory,
+// DefaultMaxMultipartMemory when zero. The rest is stored in temporary files.
+// DisallowUnknownFields rejects JSON bodies with fields that do not exist in the target.
+type BindOptions struct {
+	MaxBodyBytes          int64
+	MaxMultipartMemory    int64
+	DisallowUnknownFields bool
+}
+
+// SetBindOptions sets the options used by the Context bind helpers.
+func (w *Way) SetBindOptions(options BindOptions) {
+	w.bindOptions = options
+}
+
+// bindOptions returns the bind options of the Way instance with defaults applied.
+func (c *Context) bindOptions() BindOptions {
+	var options BindOptions
+	if c.way != nil {
+		options = c.way.bindOptions
+	}
+	if options.MaxBodyBytes <= 0 {
+		options.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if options.MaxMultipartMemory <= 0 {
+		options.MaxMultipartMemory = DefaultMaxMultipartMemory
+	}
+	return options
+}
+
+// Bind binds the request into dst.
+// Path parameters are bound first using `path` struct tags, then query parameters using
+// `query` tags for GET, HEAD and DELETE requests, then the body based on its Content-Type:
+// JSON and XML bodies are decoded into dst, form bodies are bound using `form` tags.
+// Errors are *HTTPError values with status 400, 413 or 415, so handlers can return them as is.
+func (c *Context) Bind(dst interface{}) error {
+	if isStructPointer(dst) {
+		if err := c.BindPath(dst); err != nil {
+			return err
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodDelete:
+			if err := c.BindQuery(dst); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return c.BindJSON(dst)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return c.BindXML(dst)
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		return c.BindForm(dst)
+	default:
+		return errUnsupportedMediaType(mediaType)
+	}
+}
+
+// BindJSON decodes a JSON request body into dst.
+func (c *Context) BindJSON(dst interface{}) error {
+	options := c.bindOptions()
+	decoder := json.NewDecoder(c.limitBody(options.MaxBodyBytes))
+	if options.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(dst); err != nil {
+		return bodyError(err)
+	}
+	if decoder.More() {
+		return NewHTTPError(http.StatusBadRequest, "request body must contain a single JSON value").WithCode("invalid_body")
+	}
+	return nil
+}
+
+// BindXML decodes an XML request body into dst.
+func (c *Context) BindXML(dst interface{}) error {
+	options := c.bindOptions()
+	if err := xml.NewDecoder(c.limitBody(options.MaxBodyBytes)).Decode(dst); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// BindForm binds an application/x-www-form-urlencoded or multipart/form-data body
+// into the struct pointed to by dst using `form` struct tags.
+// Multipart files bind to *multipart.FileHeader and []*multipart.FileHeader fields.
+func (c *Context) BindForm(dst interface{}) error {
+	options := c.bindOptions()
+	c.limitBody(options.MaxBodyBytes)
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	var files map[string][]*multipart.FileHeader
+	if mediaType == "multipart/form-data" {
+		if err := c.Request.ParseMultipartForm(options.MaxMultipartMemory); err != nil {
+			return bodyError(err)
+		}
+		files = c.Request.MultipartForm.File
+	} else if err := c.Request.ParseForm(); err != nil {
+		return bodyError(err)
+	}
+	return bindValues(dst, c.Request.PostForm, files, "form")
+}
+
+// BindQuery binds the URL query parameters into the struct pointed to by dst using `query` struct tags.
+func (c *Context) BindQuery(dst interface{}) error {
+	return bindValues(dst, c.Request.URL.Query(), nil, "query")
+}
+
+// BindPath binds the route path parameters into the struct pointed to by dst using `path` struct tags.
+func (c *Context) BindPath(dst interface{}) error {
+	vars := mux.Vars(c.Request)
+	values := make(map[string][]string, len(vars))
+	for key, value := range vars {
+		values[key] = []string{value}
+	}
+	return bindValues(dst, values, nil, "path")
+}
+
+// limitBody caps the request body at limit bytes and returns it.
+// The limit of the BodyLimit middleware is used instead when it is set.
+func (c *Context) limitBody(limit int64) io.Reader {
+	if c.Request.Body == nil {
+		c.Request.Body = http.NoBody
+	}
+	if c.bodyLimiter != nil && c.Request.Body == c.bodyLimiter {
+		return c.Request.Body
+	}
+	c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, limit)
+	return c.Request.Body
+}
+
+// errUnsupportedMediaType returns the error for a request body that cannot be bound.
+func errUnsupportedMediaType(mediaType string) *HTTPError {
+	if mediaType == "" {
+		mediaType = "missing"
+	}
+	return NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type %q", mediaType)).WithCode("unsupported_media_type")
+}
+
+// bodyError converts a body decoding error to an HTTPError.
+func bodyError(err error) *HTTPError {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not be larger than %d bytes", maxBytesErr.Limit)).WithCode("body_too_large").Wrap(err)
+	case errors.Is(err, io.EOF):
+		return NewHTTPError(http.StatusBadRequest, "request body is empty").WithCode("invalid_body").Wrap(err)
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return NewHTTPError(http.StatusBadRequest, "request body is malformed").WithCode("invalid_body").Wrap(err)
+	case errors.As(err, &typeErr):
+		return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for field %q", typeErr.Field)).WithCode("invalid_body").Wrap(err)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return NewHTTPError(http.StatusBadRequest, "request body contains "+strings.TrimPrefix(err.Error(), "json: ")).WithCode("invalid_body").Wrap(err)
+	default:
+		return NewHTTPError(http.StatusBadRequest, "request body is invalid").WithCode("invalid_body").Wrap(err)
+	}
+}
+
+// isStructPointer reports whether v is a non-nil pointer to a struct.
+func isStructPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindValues sets the fields of the struct pointed to by dst from values and files.
+// Fields are matched by the given struct tag, untagged fields and fields tagged "-" are skipped.
+// Embedded structs are bound recursively.
+func bindValues(dst interface{}, values map[string][]string, files map[string][]*multipart.FileHeader, tag string) error {
+	if !isStructPointer(dst) {
+		return fmt.Errorf("way: bind destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	return bindStruct(reflect.ValueOf(dst).Elem(), values, files, tag)
+}
+
+// bindStruct binds values into the fields of the struct value v.
+func bindStruct(v reflect.Value, values map[string][]string, files map[string][]*multipart.FileHeader, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct && field.IsExported() {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := bindStruct(fv, values, files, tag); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		switch field.Type {
+		case fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case fileHeaderSliceType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for %s parameter %q", tag, name)).WithCode("invalid_parameter").Wrap(err)
+		}
+	}
+	return nil
+}
+
+// setField sets v from the raw values. Slices use every value, other kinds use the first.
+func setField(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, raw[0])
+}
+
+// setValue parses s into v based on its type.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Type() {
+	case timeType:
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(parsed))
+		return nil
+	case durationType:
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(parsed))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
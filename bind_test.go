@@ -0,0 +1,153 @@
+package way
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	ID     int       `path:"id" json:"-"`
+	Name   string    `json:"name" xml:"name" form:"name"`
+	Email  string    `json:"email" xml:"email" form:"email"`
+	Tags   []string  `query:"tag" form:"tag"`
+	Page   *int      `query:"page"`
+	Active bool      `query:"active"`
+	Since  time.Time `query:"since"`
+}
+
+// bindStatus returns the HTTP status of a bind error.
+func bindStatus(t *testing.T, err error) int {
+	t.Helper()
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %v, want *HTTPError", err)
+	}
+	return httpErr.Status
+}
+
+func TestBindJSONWithPathParams(t *testing.T) {
+	w := New()
+	var got bindUser
+	w.PUT("/users/{id}", E(func(c *Context) error {
+		return c.Bind(&got)
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/users/42", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if got.ID != 42 || got.Name != "Ada" || got.Email != "ada@example.com" {
+		t.Fatalf("bound = %+v", got)
+	}
+}
+
+func TestBindQueryTypes(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?tag=a&tag=b&page=3&active=true&since=2026-01-02T03:04:05Z", nil)
+	ctx := NewContext(httptest.NewRecorder(), req, nil, nil, nil)
+
+	var got bindUser
+	if err := ctx.Bind(&got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[1] != "b" {
+		t.Fatalf("Tags = %v, want [a b]", got.Tags)
+	}
+	if got.Page == nil || *got.Page != 3 || !got.Active {
+		t.Fatalf("bound = %+v", got)
+	}
+	if !got.Since.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("Since = %v", got.Since)
+	}
+}
+
+func TestBindQueryInvalidValueIsBadRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?page=abc", nil)
+	ctx := NewContext(httptest.NewRecorder(), req, nil, nil, nil)
+
+	err := ctx.BindQuery(&bindUser{})
+	if status := bindStatus(t, err); status != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestBindXMLAndForm(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<user><name>Ada</name></user>`))
+	req.Header.Set("Content-Type", "application/xml")
+	var fromXML bindUser
+	if err := NewContext(httptest.NewRecorder(), req, nil, nil, nil).Bind(&fromXML); err != nil || fromXML.Name != "Ada" {
+		t.Fatalf("Bind(xml) = %+v, %v", fromXML, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=Grace&tag=x&tag=y"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var fromForm bindUser
+	if err := NewContext(httptest.NewRecorder(), req, nil, nil, nil).Bind(&fromForm); err != nil {
+		t.Fatalf("Bind(form) error = %v", err)
+	}
+	if fromForm.Name != "Grace" || len(fromForm.Tags) != 2 {
+		t.Fatalf("Bind(form) = %+v", fromForm)
+	}
+}
+
+func TestBindMultipartFile(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("title", "report")
+	part, _ := mw.CreateFormFile("upload", "report.txt")
+	_, _ = part.Write([]byte("contents"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	var got struct {
+		Title  string                `form:"title"`
+		Upload *multipart.FileHeader `form:"upload"`
+	}
+	if err := NewContext(httptest.NewRecorder(), req, nil, nil, nil).Bind(&got); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got.Title != "report" || got.Upload == nil || got.Upload.Filename != "report.txt" {
+		t.Fatalf("bound = %+v", got)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+		options     BindOptions
+		want        int
+	}{
+		"unsupported media type": {contentType: "text/csv", body: "a,b", want: http.StatusUnsupportedMediaType},
+		"malformed json":         {contentType: "application/json", body: `{"name":`, want: http.StatusBadRequest},
+		"unknown field":          {contentType: "application/json", body: `{"nickname":"x"}`, options: BindOptions{DisallowUnknownFields: true}, want: http.StatusBadRequest},
+		"body too large":         {contentType: "application/json", body: `{"name":"abcdefghijklmnopqrstuvwxyz"}`, options: BindOptions{MaxBodyBytes: 8}, want: http.StatusRequestEntityTooLarge},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := New()
+			w.SetBindOptions(tt.options)
+			w.POST("/users", E(func(c *Context) error {
+				return c.Bind(&bindUser{})
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			w.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
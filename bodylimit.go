@@ -0,0 +1,71 @@
+package way
+
+import (
+	"io"
+	"net/http"
+)
+
+// BodyLimit returns middleware that limits request bodies to limit bytes.
+//
+// Reading a body with a larger Content-Length, or reading past the limit, returns an
+// *http.MaxBytesError, which the bind helpers and AsHTTPError turn into a 413 response.
+// The limit replaces BindOptions.MaxBodyBytes for the bind helpers.
+//
+// BodyLimit can be used globally with Use and per route. A later BodyLimit replaces the limit
+// of an earlier one, so a route can accept larger or smaller bodies, and a limit of zero or
+// less removes it.
+func BodyLimit(limit int64) MiddlewareFunc {
+	if limit <= 0 {
+		limit = -1
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.bodyLimiter != nil && c.Request.Body == c.bodyLimiter {
+				c.bodyLimiter.limit = limit
+			} else {
+				body := c.Request.Body
+				if body == nil {
+					body = http.NoBody
+				}
+				c.bodyLimiter = &bodyLimiter{body: body, limit: limit, length: c.Request.ContentLength}
+				c.Request.Body = c.bodyLimiter
+			}
+			next(c)
+		}
+	}
+}
+
+// bodyLimiter is a request body that fails with *http.MaxBytesError after limit bytes, or
+// before reading when its Content-Length, length, is larger. A negative limit does not limit the body.
+type bodyLimiter struct {
+	body   io.ReadCloser
+	limit  int64
+	length int64
+	read   int64
+}
+
+// Read reads from the body, reading one byte past the limit to detect larger bodies.
+func (b *bodyLimiter) Read(p []byte) (int, error) {
+	if b.limit < 0 {
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		return n, err
+	}
+	if b.read > b.limit || b.length > b.limit {
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+	if remaining := b.limit - b.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &http.MaxBytesError{Limit: b.limit}
+	}
+	return n, err
+}
+
+// Close closes the body.
+func (b *bodyLimiter) Close() error {
+	return b.body.Close()
+}
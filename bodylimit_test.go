@@ -0,0 +1,84 @@
+package way
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func bodyLimitServer() *Way {
+	w := New()
+	w.Use(BodyLimit(16))
+	read := E(func(c *Context) error {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		c.String(http.StatusOK, string(body))
+		return nil
+	})
+	w.POST("/small", read)
+	w.POST("/unlimited", read, BodyLimit(0))
+	w.POST("/users", E(func(c *Context) error {
+		var user struct {
+			Name string `json:"name"`
+		}
+		if err := c.BindJSON(&user); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, user.Name)
+		return nil
+	}), BodyLimit(1024))
+	return w
+}
+
+func postBody(path, body string, chunked bool) *http.Request {
+	req := newRequest(http.MethodPost, path, strings.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	if chunked {
+		req.ContentLength = -1
+	}
+	return req
+}
+
+func TestBodyLimitRejectsLargeContentLength(t *testing.T) {
+	rec := serve(t, bodyLimitServer(), postBody("/small", strings.Repeat("a", 17), false))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Extensions["code"] != "body_too_large" || body.Detail != "request body must not be larger than 16 bytes" {
+		t.Fatalf("problem = %q %v", body.Detail, body.Extensions["code"])
+	}
+}
+
+func TestBodyLimitReadPastLimit(t *testing.T) {
+	w := bodyLimitServer()
+
+	if rec := serve(t, w, postBody("/small", strings.Repeat("a", 17), true)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if rec := serve(t, w, postBody("/small", strings.Repeat("a", 16), true)); rec.Code != http.StatusOK || rec.Body.Len() != 16 {
+		t.Fatalf("response = %d %q, want the whole body", rec.Code, rec.Body.String())
+	}
+}
+
+func TestBodyLimitPerRoute(t *testing.T) {
+	w := bodyLimitServer()
+	name := strings.Repeat("n", 100)
+
+	if rec := serve(t, w, postBody("/users", `{"name":"`+name+`"}`, false)); rec.Code != http.StatusOK || rec.Body.String() != name {
+		t.Fatalf("response = %d %q, want 200 %s", rec.Code, rec.Body.String(), name)
+	}
+	if rec := serve(t, w, postBody("/users", `{"name":"`+strings.Repeat("n", 1024)+`"}`, true)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if rec := serve(t, w, postBody("/unlimited", strings.Repeat("a", 4096), false)); rec.Code != http.StatusOK || rec.Body.Len() != 4096 {
+		t.Fatalf("response = %d, %d bytes, want the whole body", rec.Code, rec.Body.Len())
+	}
+}
@@ -0,0 +1,436 @@
+package way
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressWriter is a writer returned by an Encoder that compresses what is written to it.
+// The writers of compress/gzip and compress/flate, github.com/andybalholm/brotli and
+// github.com/klauspost/compress/zstd implement it.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Encoder returns a CompressWriter that writes a content coding to w.
+// Writers are reused with Reset, so an Encoder is only called a few times.
+type Encoder func(w io.Writer) CompressWriter
+
+// DefaultCompressTypes are the media types compressed by default.
+// Images other than SVG, audio, video and archives are already compressed and are not included.
+var DefaultCompressTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/csv",
+	"text/xml",
+	"text/javascript",
+	"application/json",
+	"application/problem+json",
+	"application/ld+json",
+	"application/manifest+json",
+	"application/xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/javascript",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// CompressConfig configures the compression middleware.
+//
+// Level is the gzip and deflate compression level, gzip.DefaultCompression when zero.
+// Brotli uses quality 5 and Zstandard its default level, which suit dynamic responses.
+// MinLength is the smallest response body in bytes that is compressed, 1024 when zero.
+// Types lists the media types that are compressed, DefaultCompressTypes when empty; an entry
+// such as "text/*" matches every subtype. Encoders adds or replaces content codings by name
+// next to the built-in "zstd", "br", "gzip" and "deflate"; a nil Encoder removes a coding.
+// When a client accepts several codings equally, the first in Preference is used, which defaults
+// to zstd, br, gzip and deflate, then any other Encoders in name order.
+// Skip reports requests whose responses are not compressed.
+type CompressConfig struct {
+	Level      int
+	MinLength  int
+	Types      []string
+	Encoders   map[string]Encoder
+	Preference []string
+	Skip       func(*Context) bool
+}
+
+// brotliLevel is the quality of the built-in Brotli encoder.
+const brotliLevel = 5
+
+// DefaultCompressConfig is the configuration used by Compress.
+var DefaultCompressConfig = CompressConfig{
+	Level:     gzip.DefaultCompression,
+	MinLength: 1024,
+}
+
+// Compress returns middleware that compresses responses with Zstandard, Brotli, gzip or deflate
+// using DefaultCompressConfig. See CompressWithConfig.
+func Compress() MiddlewareFunc {
+	return CompressWithConfig(DefaultCompressConfig)
+}
+
+// CompressWithConfig returns middleware that compresses responses with the coding negotiated
+// from the Accept-Encoding request header.
+//
+// Response bodies are buffered until MinLength bytes are written, the handler returns or the
+// response is flushed, and are then compressed when their Content-Type is in Types. A flush
+// compresses the response regardless of its length and flushes the compressor, so streaming
+// handlers keep working. Responses that already have a Content-Encoding, partial content,
+// responses to Range requests and responses with Cache-Control: no-transform are sent as is.
+// Compressible responses get Vary: Accept-Encoding, and a strong ETag is made weak when the body
+// is compressed. Context.BytesWritten counts the compressed bytes.
+//
+// It panics if Level is not a valid gzip level.
+func CompressWithConfig(config CompressConfig) MiddlewareFunc {
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+	if config.Level < gzip.HuffmanOnly || config.Level > gzip.BestCompression {
+		panic(fmt.Sprintf("way: invalid compression level %d", config.Level))
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = DefaultCompressConfig.MinLength
+	}
+	if len(config.Types) == 0 {
+		config.Types = DefaultCompressTypes
+	}
+
+	level := config.Level
+	encoders := map[string]Encoder{
+		"gzip": func(w io.Writer) CompressWriter {
+			zw, _ := gzip.NewWriterLevel(w, level)
+			return zw
+		},
+		"deflate": func(w io.Writer) CompressWriter {
+			zw, _ := flate.NewWriter(w, level)
+			return zw
+		},
+		"br": func(w io.Writer) CompressWriter {
+			return brotli.NewWriterLevel(w, brotliLevel)
+		},
+		"zstd": func(w io.Writer) CompressWriter {
+			// A window of 8 MiB is the most browsers accept, and one goroutine per writer
+			// keeps pooled encoders cheap.
+			zw, _ := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(8<<20))
+			return zw
+		},
+	}
+	for name, encoder := range config.Encoders {
+		if encoder == nil {
+			delete(encoders, strings.ToLower(name))
+			continue
+		}
+		encoders[strings.ToLower(name)] = encoder
+	}
+
+	preference := make([]string, 0, len(encoders))
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), config.Preference...), "zstd", "br", "gzip", "deflate") {
+		name = strings.ToLower(name)
+		if encoders[name] != nil && !seen[name] {
+			preference = append(preference, name)
+			seen[name] = true
+		}
+	}
+	var others []string
+	for name := range encoders {
+		if !seen[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	preference = append(preference, others...)
+
+	pools := make(map[string]*sync.Pool, len(encoders))
+	for name, encoder := range encoders {
+		pools[name] = &sync.Pool{New: func() interface{} { return encoder(io.Discard) }}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if config.Skip != nil && config.Skip(c) {
+				next(c)
+				return
+			}
+			cw := &compressWriter{
+				ResponseWriter: c.Response,
+				c:              c,
+				config:         &config,
+				partial:        c.Request.Header.Get("Range") != "",
+			}
+			if encoding := negotiateEncoding(c.Request.Header.Values("Accept-Encoding"), preference); encoding != "" {
+				cw.encoding = encoding
+				cw.pool = pools[encoding]
+			}
+			c.Response = cw
+			done := false
+			defer func() {
+				c.Response = cw.ResponseWriter
+				if !done {
+					// The handler panicked: let later error handling write a response.
+					cw.abort()
+				}
+			}()
+			next(c)
+			cw.close()
+			done = true
+		}
+	}
+}
+
+// negotiateEncoding returns the coding in preference that the Accept-Encoding values accept
+// with the highest quality, or "" if none is accepted.
+func negotiateEncoding(values []string, preference []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	quality := make(map[string]float64)
+	wildcard := -1.0
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(entry, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			q := 1.0
+			for _, param := range strings.Split(params, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+					if parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+						q = parsed
+					} else {
+						q = 0
+					}
+				}
+			}
+			if name == "*" {
+				wildcard = q
+			} else {
+				quality[name] = q
+			}
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range preference {
+		q, ok := quality[name]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers a response until it decides whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	c        *Context
+	config   *CompressConfig
+	encoding string
+	pool     *sync.Pool
+	partial  bool
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      CompressWriter
+}
+
+// WriteHeader records the status code, which is sent once the response is compressed or not.
+// Informational responses are sent right away.
+func (w *compressWriter) WriteHeader(code int) {
+	if code < http.StatusOK && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	if rw := w.c.writer; rw != nil && !rw.written {
+		// Report the response as written, as the headers can no longer be changed by the handler.
+		rw.status = code
+		rw.written = true
+	}
+	if code == http.StatusSwitchingProtocols {
+		w.decide(false)
+	}
+}
+
+// Write buffers b until MinLength bytes are written and then compresses it if possible.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.config.MinLength {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush compresses the response regardless of its length and sends any buffered data.
+func (w *compressWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError is Flush reporting whether the underlying writer supports flushing.
+// It is used by http.ResponseController.
+func (w *compressWriter) FlushError() error {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return err
+		}
+	}
+	if w.zw != nil {
+		if err := w.zw.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection. The response is not compressed.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header and buffered body, compressing them when the response qualifies.
+// long reports whether the body is long enough to compress.
+func (w *compressWriter) decide(long bool) error {
+	w.decided = true
+	header := w.Header()
+	if w.compressible() {
+		addVary(header, "Accept-Encoding")
+		if long && w.pool != nil {
+			header.Del("Content-Length")
+			header.Set("Content-Encoding", w.encoding)
+			if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				header.Set("ETag", "W/"+etag)
+			}
+			w.zw = w.pool.Get().(CompressWriter)
+			w.zw.Reset(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible reports whether the response may be compressed.
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	switch {
+	case w.partial, w.status < http.StatusOK, w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified, w.status == http.StatusPartialContent:
+		return false
+	case header.Get("Content-Encoding") != "", header.Get("Content-Range") != "":
+		return false
+	case strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-transform"):
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" && len(w.buf) > 0 {
+		// Set the type net/http would detect, since it cannot detect it from compressed data.
+		contentType = http.DetectContentType(w.buf)
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range w.config.Types {
+		t = strings.ToLower(t)
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// close finishes the response after the handler returned.
+func (w *compressWriter) close() {
+	if !w.decided && w.status != 0 {
+		if err := w.decide(len(w.buf) >= w.config.MinLength); err != nil {
+			w.c.Slog().Error("failed to write compressed response", "error", err)
+		}
+	}
+	if w.zw != nil {
+		if err := w.zw.Close(); err != nil {
+			w.c.Slog().Error("failed to write compressed response", "error", err)
+		}
+		w.release()
+	}
+}
+
+// abort discards a response that has not been sent, so that an error response can be written.
+func (w *compressWriter) abort() {
+	if !w.decided && w.status != 0 {
+		if rw := w.c.writer; rw != nil {
+			rw.status = 0
+			rw.written = false
+		}
+		return
+	}
+	if w.zw != nil {
+		_ = w.zw.Close()
+		w.release()
+	}
+}
+
+// release returns the compressor to its pool.
+func (w *compressWriter) release() {
+	w.zw.Reset(io.Discard)
+	w.pool.Put(w.zw)
+	w.zw = nil
+}
@@ -0,0 +1,237 @@
+package way
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compressServer(config CompressConfig) *Way {
+	w := New()
+	w.Use(CompressWithConfig(config))
+	w.GET("/large", func(c *Context) {
+		c.JSON(http.StatusOK, map[string]string{"text": strings.Repeat("way ", 512)})
+	})
+	w.GET("/small", func(c *Context) {
+		c.String(http.StatusOK, "small")
+	})
+	w.GET("/image", func(c *Context) {
+		c.Image(http.StatusOK, "image/png", make([]byte, 4096))
+	})
+	w.GET("/data", func(c *Context) {
+		c.Data(http.StatusOK, []byte("<!DOCTYPE html><html>"+strings.Repeat("<p>way</p>", 200)+"</html>"))
+	})
+	return w
+}
+
+func compressGet(path, acceptEncoding string) *http.Request {
+	return newRequest(http.MethodGet, path, nil, map[string]string{"Accept-Encoding": acceptEncoding})
+}
+
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompressGzipJSON(t *testing.T) {
+	rec := serve(t, compressServer(CompressConfig{}), compressGet("/large", "br;q=0.5, gzip;q=0.8"))
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("Vary = %q, want Accept-Encoding", got)
+	}
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("Content-Length") != "" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	if body := gunzip(t, rec.Body); !strings.Contains(body, `"text":"way way`) {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestCompressSkipsSmallAndCompressedResponses(t *testing.T) {
+	w := compressServer(CompressConfig{})
+
+	rec := serve(t, w, compressGet("/small", "gzip"))
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != "small" {
+		t.Fatalf("small response = %q %q", rec.Header().Get("Content-Encoding"), rec.Body.String())
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("small response Vary = %q, want Accept-Encoding", got)
+	}
+
+	rec = serve(t, w, compressGet("/image", "gzip"))
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" || rec.Body.Len() != 4096 {
+		t.Fatalf("image response = %v, %d bytes", rec.Header(), rec.Body.Len())
+	}
+
+	rec = serve(t, w, compressGet("/large", ""))
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("response without Accept-Encoding = %v", rec.Header())
+	}
+}
+
+func TestCompressDetectsContentType(t *testing.T) {
+	rec := serve(t, compressServer(CompressConfig{}), compressGet("/data", "deflate"))
+
+	if rec.Header().Get("Content-Encoding") != "deflate" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	body, err := io.ReadAll(flate.NewReader(rec.Body))
+	if err != nil || !strings.HasPrefix(string(body), "<!DOCTYPE html>") {
+		t.Fatalf("body = %q, error = %v", body, err)
+	}
+}
+
+func TestCompressBrotliAndZstd(t *testing.T) {
+	w := compressServer(CompressConfig{})
+
+	rec := serve(t, w, compressGet("/large", "gzip, deflate, br"))
+	if got := rec.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("Content-Encoding = %q, want br", got)
+	}
+	body, err := io.ReadAll(brotli.NewReader(rec.Body))
+	if err != nil || !strings.Contains(string(body), `"text":"way way`) {
+		t.Fatalf("br body = %q, error = %v", body, err)
+	}
+
+	rec = serve(t, w, compressGet("/large", "gzip, deflate, br, zstd"))
+	if got := rec.Header().Get("Content-Encoding"); got != "zstd" {
+		t.Fatalf("Content-Encoding = %q, want zstd", got)
+	}
+	zr, err := zstd.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	body, err = io.ReadAll(zr)
+	if err != nil || !strings.Contains(string(body), `"text":"way way`) {
+		t.Fatalf("zstd body = %q, error = %v", body, err)
+	}
+}
+
+func TestCompressCustomEncoderPreference(t *testing.T) {
+	// A gzip writer replaces the built-in Brotli encoder.
+	config := CompressConfig{Encoders: map[string]Encoder{
+		"br": func(w io.Writer) CompressWriter { return gzip.NewWriter(w) },
+	}}
+	w := compressServer(config)
+
+	if got := serve(t, w, compressGet("/large", "gzip, br")).Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("Content-Encoding = %q, want br", got)
+	}
+	config.Preference = []string{"gzip"}
+	w = compressServer(config)
+	if got := serve(t, w, compressGet("/large", "gzip, br")).Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding with preference = %q, want gzip", got)
+	}
+}
+
+func TestCompressFlushStreamsResponse(t *testing.T) {
+	w := New()
+	w.Use(Compress())
+	chunks := make(chan string)
+	w.GET("/events", func(c *Context) {
+		c.Response.Header().Set("Content-Type", "text/plain")
+		for _, chunk := range []string{"first\n", "second\n"} {
+			if _, err := io.WriteString(c.Response, chunk); err != nil {
+				t.Error(err)
+			}
+			if err := http.NewResponseController(c.Response).Flush(); err != nil {
+				t.Error(err)
+			}
+			<-chunks
+		}
+	})
+	server := httptest.NewServer(w)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"first\n", "second\n"} {
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(zr, got); err != nil || string(got) != want {
+			t.Fatalf("chunk = %q, error = %v, want %q", got, err, want)
+		}
+		chunks <- want
+	}
+}
+
+func TestCompressPanicLetsRecoverRespond(t *testing.T) {
+	w := New()
+	w.Use(Recover(), Compress())
+	w.GET("/panic", func(c *Context) {
+		c.Response.Header().Set("Content-Type", "text/plain")
+		c.Response.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(c.Response, "partial")
+		panic("boom")
+	})
+
+	rec := serve(t, w, compressGet("/panic", "gzip"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(rec.Body.String(), "partial") {
+		t.Fatalf("buffered body was sent: %q", rec.Body.String())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	preference := []string{"br", "gzip", "deflate"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "gzip, deflate, br", want: "br"},
+		{header: "deflate;q=1, gzip;q=0.5", want: "deflate"},
+		{header: "gzip;q=0", want: ""},
+		{header: "*", want: "br"},
+		{header: "*;q=0.1, br;q=0", want: "gzip"},
+		{header: "identity", want: ""},
+		{header: "GZIP; Q=0.9", want: "gzip"},
+		{header: "gzip;q=bad, deflate", want: "deflate"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding([]string{tt.header}, preference); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressInvalidLevelPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("CompressWithConfig() did not panic")
+		}
+	}()
+	CompressWithConfig(CompressConfig{Level: 42})
+}
@@ -7,15 +7,20 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/swayedev/way/crypto"
+	"github.com/swayedev/way/database"
+	"github.com/swayedev/way/trace"
 )
 
 // Response is the standard go HTTP response writer.
@@ -29,6 +34,43 @@ type Context struct {
 	Session    *Session
 	Logger     *log.Logger
 	HTTPClient *http.Client
+	mu         sync.RWMutex
+	// keys holds request-scoped values shared between middleware and handlers.
+	keys map[string]interface{}
+	// way is the Way instance that created the context, nil for contexts created with NewContext.
+	way *Way
+	// writer tracks the response status, nil for contexts created with NewContext.
+	writer *responseWriter
+	// slogger is the Way structured logger, nil for contexts created with NewContext.
+	slogger *slog.Logger
+	// requestID is the request ID set by the RequestID middleware.
+	requestID string
+	// requestIDHeader is the header the request ID was read from.
+	requestIDHeader string
+	// cspNonce is the Content-Security-Policy nonce set by SecureHeaders.
+	cspNonce string
+	// csrfToken is the masked CSRF token set by CSRF.
+	csrfToken string
+	// timeout is the response writer of the first Timeout middleware.
+	timeout *timeoutWriter
+	// bodyLimiter is the request body limit set by BodyLimit.
+	bodyLimiter *bodyLimiter
+	// etag is the configuration set by the ETag middleware.
+	etag *ETagConfig
+	// sse is the event stream started with SSE.
+	sse *EventStream
+}
+
+// contextKey is the key used to store the Way Context in the request context.
+type contextKey struct{}
+
+// contextFromRequest returns the Way Context stored in the request context, if any.
+func contextFromRequest(r *http.Request) *Context {
+	if r == nil {
+		return nil
+	}
+	ctx, _ := r.Context().Value(contextKey{}).(*Context)
+	return ctx
 }
 
 func NewContext(w http.ResponseWriter, r *http.Request, d *DB, s *Session, l *log.Logger) *Context {
@@ -44,12 +86,75 @@ func newContextWithHTTPClient(w http.ResponseWriter, r *http.Request, d *DB, s *
 	}
 	return &Context{Response: w, Request: r, db: d, Session: s, Logger: l, HTTPClient: client}
 }
+
+// Log returns the logger.
+// For contexts created by Way it writes to Slog, so records include the request attributes,
+// unless Logger has been replaced.
 func (c *Context) Log() *log.Logger {
+	if c.way != nil && c.slogger != nil && c.Logger == c.way.Logger {
+		return slog.NewLogLogger(c.Slog().Handler(), slog.LevelInfo)
+	}
 	if c.Logger != nil {
 		return c.Logger
 	}
 	return defaultLogger()
 }
+
+// Set stores a value on the context for the lifetime of the request.
+// Values set by middleware are visible to later middleware and the route handler.
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string]interface{})
+	}
+	c.keys[key] = value
+}
+
+// Get returns the value stored under key and whether it exists.
+func (c *Context) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.keys[key]
+	return value, ok
+}
+
+// GetString returns the value stored under key as a string.
+// It returns an empty string if the key does not exist or is not a string.
+func (c *Context) GetString(key string) string {
+	value, _ := GetAs[string](c, key)
+	return value
+}
+
+// GetInt returns the value stored under key as an int.
+// It returns 0 if the key does not exist or is not an int.
+func (c *Context) GetInt(key string) int {
+	value, _ := GetAs[int](c, key)
+	return value
+}
+
+// GetBool returns the value stored under key as a bool.
+// It returns false if the key does not exist or is not a bool.
+func (c *Context) GetBool(key string) bool {
+	value, _ := GetAs[bool](c, key)
+	return value
+}
+
+// GetAs returns the value stored under key as type T.
+// The boolean result is false if the key does not exist or holds a value of a different type.
+func GetAs[T any](c *Context, key string) (T, bool) {
+	var zero T
+	value, ok := c.Get(key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
 func (c *Context) SetSession(s *Session) {
 	c.Session = s
 }
@@ -61,10 +166,10 @@ func (c *Context) GetDB() *DB {
 func (c *Context) GetSession(name string) sessions.Store {
 	store, err := c.GetSessionE(name)
 	if err != nil {
-		c.Log().Printf("Session store not found: %s", name)
+		c.Slog().Warn("session store not found", "store", name, "error", err)
 		return nil
 	}
-	c.Log().Printf("Session store retrieved: %s", name)
+	c.Slog().Debug("session store retrieved", "store", name)
 	return store
 }
 
@@ -93,96 +198,83 @@ func (c *Context) Parm(param string) string {
 }
 
 func (c *Context) SqlExec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	c.Log().Printf("Executing SQL query")
-	result, err := c.db.SQLExec(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing SQL query: %v", err)
-	}
-	return result, err
+	return c.db.SQLExec(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) SqlExecNoResult(ctx context.Context, query string, args ...interface{}) error {
-	c.Log().Printf("Executing SQL query with no result")
-	err := c.db.SQLExecNoResult(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing SQL query with no result: %v", err)
-	}
-	return err
+	return c.db.SQLExecNoResult(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) SqlQuery(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	c.Log().Printf("Executing SQL query")
-	rows, err := c.db.SQLQuery(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing SQL query: %v", err)
-	}
-	return rows, err
+	return c.db.SQLQuery(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) SqlQueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	c.Log().Printf("Executing SQL query row")
-	row := c.db.SQLQueryRow(ctx, query, args...)
-	return row
+	return c.db.SQLQueryRow(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) PgxExec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
-	c.Log().Printf("Executing PGX query")
-	commandTag, err := c.db.PGXExec(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing PGX query: %v", err)
-	}
-	return commandTag, err
+	return c.db.PGXExec(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) PgxExecNoResult(ctx context.Context, query string, args ...interface{}) error {
-	c.Log().Printf("Executing PGX query with no result")
-	err := c.db.PGXExecNoResult(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing PGX query with no result: %v", err)
-	}
-	return err
+	return c.db.PGXExecNoResult(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) PgxQuery(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
-	c.Log().Printf("Executing PGX query")
-	rows, err := c.db.PGXQuery(ctx, query, args...)
-	if err != nil {
-		c.Log().Printf("Error executing PGX query: %v", err)
-	}
-	return rows, err
+	return c.db.PGXQuery(c.dbContext(ctx), query, args...)
 }
 
 func (c *Context) PgxQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
-	c.Log().Printf("Executing PGX query row")
-	row := c.db.PGXQueryRow(ctx, query, args...)
-	return row
+	return c.db.PGXQueryRow(c.dbContext(ctx), query, args...)
+}
+
+// dbContext returns ctx carrying the request logger, with the database driver as an attribute,
+// for the database package to log queries to.
+func (c *Context) dbContext(ctx context.Context) context.Context {
+	logger := c.Slog()
+	if c.db != nil {
+		logger = logger.With(slog.String("driver", c.db.Driver))
+	}
+	return database.ContextWithLogger(ctx, logger)
 }
 
 func (c *Context) Redirect(code int, url string) {
-	c.Log().Printf("Redirecting with status code: %d", code)
+	c.Slog().Debug("redirecting", "status", code, "location", url)
 	http.Redirect(c.Response, c.Request, url, code)
 }
 
 func (c *Context) JSON(code int, i interface{}) {
+	c.writeJSON(code, "application/json", i)
+}
+
+// writeJSON encodes i and writes it with the given status code and content type.
+func (c *Context) writeJSON(code int, contentType string, i interface{}) {
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(i); err != nil {
-		c.Log().Printf("Error encoding JSON response: %v", err)
+		c.Slog().Error("failed to encode JSON response", "error", err)
 		http.Error(c.Response, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Header().Set("Content-Type", contentType)
+	if c.notModifiedBody(code, body.Bytes()) {
+		return
+	}
 	c.Response.WriteHeader(code)
 	if _, err := c.Response.Write(body.Bytes()); err != nil {
-		c.Log().Printf("Error writing JSON response: %v", err)
+		c.Slog().Error("failed to write JSON response", "error", err)
 	}
 }
 
 // HTML sends an HTML response with the specified status code.
 func (c *Context) HTML(code int, htmlContent string) {
 	c.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if c.notModifiedBody(code, []byte(htmlContent)) {
+		return
+	}
 	c.Response.WriteHeader(code)
 	if _, err := c.Response.Write([]byte(htmlContent)); err != nil {
-		c.Log().Printf("Error writing HTML response: %v", err)
+		c.Slog().Error("failed to write HTML response", "error", err)
 	}
 }
 
@@ -193,55 +285,58 @@ func (c *Context) String(code int, i interface{}) {
 	case string:
 		_, err := c.Response.Write([]byte(v))
 		if err != nil {
-			c.Log().Printf("Error writing string response: %v", err)
+			c.Slog().Error("failed to write string response", "error", err)
 		}
 	case []byte:
 		_, err := c.Response.Write(v)
 		if err != nil {
-			c.Log().Printf("Error writing byte response: %v", err)
+			c.Slog().Error("failed to write byte response", "error", err)
 		}
 	default:
-		c.Log().Printf("Error encoding string response: unsupported type %T", v)
+		c.Slog().Error("failed to encode string response", "type", fmt.Sprintf("%T", v))
 	}
 }
 
 func (c *Context) XML(code int, i interface{}) {
 	var body bytes.Buffer
 	if err := xml.NewEncoder(&body).Encode(i); err != nil {
-		c.Log().Printf("Error encoding XML response: %v", err)
+		c.Slog().Error("failed to encode XML response", "error", err)
 		http.Error(c.Response, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 	c.Response.Header().Set("Content-Type", "application/xml")
 	c.Response.WriteHeader(code)
 	if _, err := c.Response.Write(body.Bytes()); err != nil {
-		c.Log().Printf("Error writing XML response: %v", err)
+		c.Slog().Error("failed to write XML response", "error", err)
 	}
 }
 
 func (c *Context) Data(code int, data []byte) {
+	if c.notModifiedBody(code, data) {
+		return
+	}
 	c.Response.WriteHeader(code)
 	if _, err := c.Response.Write(data); err != nil {
-		c.Log().Printf("Error writing data: %v", err)
+		c.Slog().Error("failed to write data", "error", err)
 	}
 }
 
 func (c *Context) Status(code int) {
 	c.Response.WriteHeader(code)
-	c.Log().Printf("Status set to %d", code)
+	c.Slog().Debug("status set", "status", code)
 }
 
 func (c *Context) Image(code int, contentType string, imageData []byte) {
 	c.Response.Header().Set("Content-Type", contentType)
 	c.Response.WriteHeader(code)
 	if _, err := c.Response.Write(imageData); err != nil {
-		c.Log().Printf("Error writing image data: %v", err)
+		c.Slog().Error("failed to write image data", "error", err)
 	}
 }
 
 func (c *Context) SetHeader(key string, value string) {
 	c.Response.Header().Set(key, value)
-	c.Log().Printf("Header set: %s", key)
+	c.Slog().Debug("header set", "header", key)
 }
 
 func (c *Context) ProxyMedia(mediaURL string) {
@@ -249,13 +344,31 @@ func (c *Context) ProxyMedia(mediaURL string) {
 	if client == nil {
 		client = defaultHTTPClient()
 	}
-	resp, err := client.Get(mediaURL)
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, mediaURL, nil)
+	if err != nil {
+		c.Slog().Error("failed to create media request", "url", mediaURL, "error", err)
+		http.Error(c.Response, "Failed to fetch media", http.StatusInternalServerError)
+		return
+	}
+	if c.requestID != "" {
+		req.Header.Set(c.requestIDHeader, c.requestID)
+	}
+	ctx, span := trace.Start(req.Context(), "GET", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttribute("http.request.method", http.MethodGet),
+		trace.WithAttribute("server.address", req.URL.Host),
+	)
+	defer span.End()
+	req = req.WithContext(ctx)
+	trace.Inject(ctx, req.Header)
+	resp, err := client.Do(req)
 	if err != nil {
-		c.Log().Printf("Failed to fetch media: %v", err)
+		span.RecordError(err)
+		c.Slog().Error("failed to fetch media", "url", mediaURL, "error", err)
 		http.Error(c.Response, "Failed to fetch media", http.StatusInternalServerError)
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
 
 	// Copy the headers
 	for key, values := range resp.Header {
@@ -267,13 +380,13 @@ func (c *Context) ProxyMedia(mediaURL string) {
 	// Stream the content
 	c.Response.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(c.Response, resp.Body); err != nil {
-		c.Log().Printf("Error streaming media: %v", err)
+		c.Slog().Error("failed to stream media", "url", mediaURL, "error", err)
 	}
 }
 
 func (c *Context) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.Response, cookie)
-	c.Log().Printf("Cookie set: %s", cookie.Name)
+	c.Slog().Debug("cookie set", "cookie", cookie.Name)
 }
 
 func (c *Context) GetCookie(name string) (*http.Cookie, error) {
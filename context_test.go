@@ -129,3 +129,57 @@ type roundTripFunc func(*http.Request) (*http.Response, error)
 func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+func TestContextSetGet(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil, nil, nil)
+
+	ctx.Set("user", "ada")
+	ctx.Set("attempts", 3)
+	ctx.Set("admin", true)
+
+	if got := ctx.GetString("user"); got != "ada" {
+		t.Fatalf("GetString() = %q, want ada", got)
+	}
+	if got := ctx.GetInt("attempts"); got != 3 {
+		t.Fatalf("GetInt() = %d, want 3", got)
+	}
+	if !ctx.GetBool("admin") {
+		t.Fatal("GetBool() = false, want true")
+	}
+	if _, ok := ctx.Get("missing"); ok {
+		t.Fatal("Get(missing) ok = true, want false")
+	}
+	if _, ok := GetAs[int](ctx, "user"); ok {
+		t.Fatal("GetAs[int](user) ok = true, want false for mismatched type")
+	}
+}
+
+func TestContextSharedBetweenMiddlewareAndHandler(t *testing.T) {
+	type tenant struct{ ID string }
+	w := New()
+	var middlewareCtx *Context
+	w.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			middlewareCtx = c
+			c.Set("tenant", &tenant{ID: "acme"})
+			next(c)
+		}
+	})
+	w.GET("/tenant/{id}", func(c *Context) {
+		if c != middlewareCtx {
+			t.Error("handler received a different *Context than middleware")
+		}
+		tn, ok := GetAs[*tenant](c, "tenant")
+		if !ok || tn.ID != "acme" {
+			t.Errorf("tenant = %v, %v; want acme", tn, ok)
+		}
+		c.String(http.StatusOK, c.Parm("id"))
+	})
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenant/42", nil))
+
+	if rec.Body.String() != "42" {
+		t.Fatalf("body = %q, want 42", rec.Body.String())
+	}
+}
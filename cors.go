@@ -0,0 +1,214 @@
+package way
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultCORSMethods are the methods allowed by the CORS middleware when CORSConfig.AllowMethods is empty.
+var DefaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// CORSConfig configures the CORS middleware.
+//
+// AllowOrigins lists the allowed origins. An entry may be "*" to allow any origin, an exact
+// origin such as "https://example.com", or a pattern with one "*" in place of subdomains such as
+// "https://*.example.com", which matches https://api.example.com but not https://example.com.
+// AllowOriginFunc is consulted for origins that do not match AllowOrigins.
+//
+// AllowMethods defaults to DefaultCORSMethods. When AllowHeaders is empty the headers requested
+// by a preflight are allowed. MaxAge is the number of seconds browsers may cache a preflight
+// response; zero omits the header and a negative value sends 0 to disable caching.
+// AllowPrivateNetwork answers Private Network Access preflights from public origins.
+type CORSConfig struct {
+	AllowOrigins        []string
+	AllowOriginFunc     func(origin string) bool
+	AllowMethods        []string
+	AllowHeaders        []string
+	ExposeHeaders       []string
+	AllowCredentials    bool
+	MaxAge              int
+	AllowPrivateNetwork bool
+}
+
+// CORS returns middleware that implements Cross-Origin Resource Sharing.
+// Preflight requests from allowed origins are answered with 204 No Content and are not passed on.
+// Other requests from allowed origins get the Access-Control-Allow-Origin, -Allow-Credentials
+// and -Expose-Headers headers, and requests from other origins are served without CORS headers.
+//
+// Register it with Pre so that preflights are answered for routes registered only with GET,
+// POST and the other method helpers; with Use it only sees requests that match a route and method.
+// It panics if an origin pattern contains more than one "*", or if AllowOrigins contains "*" and
+// AllowCredentials is set, since that would let every site make credentialed requests.
+func CORS(config CORSConfig) MiddlewareFunc {
+	origins := newCORSOrigins(config.AllowOrigins)
+	if origins.any && config.AllowCredentials {
+		panic(`way: CORS AllowOrigins "*" cannot be used with AllowCredentials`)
+	}
+	methods := config.AllowMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.Itoa(config.MaxAge)
+	} else if config.MaxAge < 0 {
+		maxAge = "0"
+	}
+	// A wildcard is answered with "*", which does not depend on the Origin.
+	anyOrigin := origins.any
+
+	allowed := func(origin string) bool {
+		if origins.match(origin) {
+			return true
+		}
+		return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			header := c.Response.Header()
+			origin := c.Request.Header.Get("Origin")
+			preflight := c.Request.Method == http.MethodOptions && origin != "" &&
+				c.Request.Header.Get("Access-Control-Request-Method") != ""
+
+			if preflight {
+				addVary(header, "Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers")
+				if config.AllowPrivateNetwork {
+					addVary(header, "Access-Control-Request-Private-Network")
+				}
+			} else if !anyOrigin {
+				addVary(header, "Origin")
+			}
+
+			if origin == "" || !allowed(origin) {
+				if preflight {
+					c.Response.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next(c)
+				return
+			}
+
+			if anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposeHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next(c)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+			} else if requested := c.Request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+			if maxAge != "" {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			if config.AllowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+				header.Set("Access-Control-Allow-Private-Network", "true")
+			}
+			c.Response.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// corsOrigins matches request origins against the configured origins and patterns.
+type corsOrigins struct {
+	any      bool
+	exact    map[string]bool
+	patterns []corsPattern
+}
+
+// corsPattern is an origin with one "*" standing for one or more subdomain labels.
+type corsPattern struct {
+	prefix string
+	suffix string
+}
+
+// newCORSOrigins parses the AllowOrigins entries.
+func newCORSOrigins(allowOrigins []string) corsOrigins {
+	origins := corsOrigins{exact: make(map[string]bool)}
+	for _, origin := range allowOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch strings.Count(origin, "*") {
+		case 0:
+			origins.exact[origin] = true
+		case 1:
+			if origin == "*" {
+				origins.any = true
+				continue
+			}
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			origins.patterns = append(origins.patterns, corsPattern{prefix: prefix, suffix: suffix})
+		default:
+			panic("way: invalid CORS origin pattern " + strconv.Quote(origin))
+		}
+	}
+	return origins
+}
+
+// match reports whether origin is allowed by the configured origins.
+func (o corsOrigins) match(origin string) bool {
+	if o.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if o.exact[origin] {
+		return true
+	}
+	for _, p := range o.patterns {
+		if len(origin) > len(p.prefix)+len(p.suffix) &&
+			strings.HasPrefix(origin, p.prefix) && strings.HasSuffix(origin, p.suffix) &&
+			isSubdomain(origin[len(p.prefix):len(origin)-len(p.suffix)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubdomain reports whether s only contains host name characters, so a pattern
+// cannot match an origin with a different scheme, port or user info.
+func isSubdomain(s string) bool {
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9', ch == '-':
+		case ch == '.' && i > 0 && i < len(s)-1 && s[i-1] != '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// addVary adds values to the Vary header unless they are already listed.
+func addVary(header http.Header, values ...string) {
+	for _, value := range values {
+		found := false
+		for _, line := range header.Values("Vary") {
+			for _, v := range strings.Split(line, ",") {
+				if v = strings.TrimSpace(v); v == "*" || strings.EqualFold(v, value) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			header.Add("Vary", value)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package way
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func corsServer(config CORSConfig) *Way {
+	w := New()
+	w.Pre(CORS(config))
+	w.GET("/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+	w.POST("/items", func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	return w
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	return newRequest(http.MethodOptions, "/items", nil, map[string]string{
+		"Origin":                         origin,
+		"Access-Control-Request-Method":  method,
+		"Access-Control-Request-Headers": headers,
+	})
+}
+
+func TestCORSPreflightForGetOnlyRoute(t *testing.T) {
+	w := corsServer(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+
+	rec := serve(t, w, preflight("https://app.example.com", http.MethodPost, "Content-Type, X-Token"))
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Token",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Fatalf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if got := strings.Join(rec.Header().Values("Vary"), ", "); got != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+		t.Fatalf("Vary = %q", got)
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	w := corsServer(CORSConfig{
+		AllowOrigins:  []string{"https://app.example.com"},
+		ExposeHeaders: []string{"X-Total-Count"},
+	})
+
+	rec := serve(t, w, newRequest(http.MethodGet, "/items", nil, map[string]string{"Origin": "https://app.example.com"}))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "items" {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Fatalf("Access-Control-Expose-Headers = %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORSRejectsUnknownOrigin(t *testing.T) {
+	w := corsServer(CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+
+	rec := serve(t, w, preflight("https://evil.example", http.MethodPost, ""))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("preflight = %d %v, want 204 without CORS headers", rec.Code, rec.Header())
+	}
+
+	rec = serve(t, w, newRequest(http.MethodGet, "/items", nil, map[string]string{"Origin": "https://evil.example"}))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("request = %d %v, want 200 without CORS headers", rec.Code, rec.Header())
+	}
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	w := corsServer(CORSConfig{AllowOrigins: []string{"*"}})
+	rec := serve(t, w, newRequest(http.MethodGet, "/items", nil, map[string]string{"Origin": "https://anywhere.test"}))
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "" {
+		t.Fatalf("Vary = %q, want none", got)
+	}
+}
+
+func TestCORSWildcardWithCredentialsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal(`CORS() with "*" and AllowCredentials did not panic`)
+		}
+	}()
+	CORS(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCORSOriginPatternsAndFunc(t *testing.T) {
+	origins := newCORSOrigins([]string{"https://*.example.com", "http://localhost:3000"})
+	tests := map[string]bool{
+		"https://api.example.com":       true,
+		"https://a.b.example.com":       true,
+		"HTTPS://API.EXAMPLE.COM":       true,
+		"http://localhost:3000":         true,
+		"https://example.com":           false,
+		"http://api.example.com":        false,
+		"https://evil.com/.example.com": false,
+		"https://evilexample.com":       false,
+		"https://x..example.com":        false,
+		"http://localhost:3001":         false,
+	}
+	for origin, want := range tests {
+		if got := origins.match(origin); got != want {
+			t.Errorf("match(%q) = %v, want %v", origin, got, want)
+		}
+	}
+
+	w := corsServer(CORSConfig{AllowOriginFunc: func(origin string) bool {
+		return strings.HasSuffix(origin, ".internal")
+	}})
+	rec := serve(t, w, preflight("http://admin.internal", http.MethodGet, ""))
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://admin.internal" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+}
+
+func TestCORSPrivateNetwork(t *testing.T) {
+	w := corsServer(CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowPrivateNetwork: true})
+	req := preflight("https://app.example.com", http.MethodGet, "")
+	req.Header.Set("Access-Control-Request-Private-Network", "true")
+	rec := serve(t, w, req)
+	if got := rec.Header().Get("Access-Control-Allow-Private-Network"); got != "true" {
+		t.Fatalf("Access-Control-Allow-Private-Network = %q, want true", got)
+	}
+}
+
+func TestCORSInvalidPatternPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for an origin with two wildcards")
+		}
+	}()
+	CORS(CORSConfig{AllowOrigins: []string{"https://*.*.example.com"}})
+}
+
+func TestPreRunsForUnmatchedRoutes(t *testing.T) {
+	w := New()
+	var order []string
+	w.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			order = append(order, "pre")
+			c.Set("pre", true)
+			next(c)
+		}
+	})
+	w.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			order = append(order, "use")
+			next(c)
+		}
+	})
+	w.GET("/ok", func(c *Context) {
+		if !c.GetBool("pre") {
+			t.Error("handler does not see values set by Pre middleware")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	serve(t, w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if got := strings.Join(order, ","); got != "pre,use,pre" {
+		t.Fatalf("order = %q, want pre,use,pre", got)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -41,6 +41,18 @@ func HashByte(data []byte) [32]byte {
 	return sha3.Sum256([]byte(data))
 }
 
+// HashReader calculates the SHA3-256 hash of the data read from r until EOF, without
+// reading it all into memory. It returns the same hash as HashByte for the same data.
+func HashReader(r io.Reader) ([32]byte, error) {
+	var sum [32]byte
+	h := sha3.New256()
+	if _, err := io.Copy(h, r); err != nil {
+		return sum, err
+	}
+	h.Sum(sum[:0])
+	return sum, nil
+}
+
 // Encrypt encrypts the given data using the provided passphrase.
 // It returns the encrypted data as a hexadecimal string and any error encountered.
 func Encrypt(data []byte, passphrase string) (string, error) {
@@ -40,3 +40,14 @@ func TestHashStringToStringUsesFcryptSHA3(t *testing.T) {
 		t.Fatalf("HashStringToString() = %s, want %s", got, want)
 	}
 }
+
+func TestHashReaderMatchesHashByte(t *testing.T) {
+	data := bytes.Repeat([]byte("hello"), 10000)
+	got, err := HashReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("HashReader() error = %v", err)
+	}
+	if want := HashByte(data); got != want {
+		t.Fatalf("HashReader() = %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package way
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrCSRFTokenMissing is wrapped by the error the CSRF middleware passes to the error handler
+	// when an unsafe request has no token in the header or form field.
+	ErrCSRFTokenMissing = errors.New("CSRF token missing")
+	// ErrCSRFTokenInvalid is wrapped when the token is malformed or does not match the token cookie.
+	ErrCSRFTokenInvalid = errors.New("CSRF token invalid")
+	// ErrCSRFOriginMismatch is wrapped when the Origin or Sec-Fetch-Site header shows the request
+	// comes from another origin that is not trusted.
+	ErrCSRFOriginMismatch = errors.New("CSRF origin not allowed")
+)
+
+// csrfTokenLength is the number of random bytes in a CSRF token.
+const csrfTokenLength = 32
+
+// CSRFConfig configures the CSRF middleware.
+//
+// Cookie is the name of the securecookie codec in the Session used to sign and encrypt
+// the token cookie, the default cookie codec when empty. CookieName is the name of the
+// token cookie, "_csrf" when empty, and CookieMaxAge its lifetime in seconds, 12 hours when zero.
+// The cookie is HttpOnly, SameSite=Lax and Secure unless InsecureCookie is set for local development.
+//
+// Header and FormField are where the token is read from on unsafe requests,
+// "X-CSRF-Token" and "csrf_token" when empty. Exempt lists route templates, such as
+// "/webhooks/{provider}", or paths that are not checked, and ExemptFunc reports additional exemptions.
+// TrustedOrigins lists origins, such as "https://admin.example.com", allowed to send unsafe
+// requests besides the request's own host.
+type CSRFConfig struct {
+	Cookie         string
+	CookieName     string
+	CookiePath     string
+	CookieDomain   string
+	CookieMaxAge   int
+	InsecureCookie bool
+	Header         string
+	FormField      string
+	Exempt         []string
+	ExemptFunc     func(*Context) bool
+	TrustedOrigins []string
+}
+
+// CSRF returns middleware that protects unsafe requests against cross-site request forgery.
+//
+// A random token is kept in a cookie encoded with the Session's securecookie codec, and a
+// masked copy, different on every request, is available from Context.CSRFToken for forms and
+// JavaScript clients. Requests with methods other than GET, HEAD, OPTIONS and TRACE must send
+// the masked token in the header or form field, and must not come from another origin according
+// to the Origin and Sec-Fetch-Site headers. Failures are passed to the error handler as a
+// 403 *HTTPError wrapping ErrCSRFTokenMissing, ErrCSRFTokenInvalid or ErrCSRFOriginMismatch.
+// A missing codec is reported as a 500 error.
+//
+// Register it with Use, after any middleware that must run for rejected requests.
+func CSRF(config CSRFConfig) MiddlewareFunc {
+	if config.CookieName == "" {
+		config.CookieName = "_csrf"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = int((12 * time.Hour).Seconds())
+	}
+	if config.Header == "" {
+		config.Header = "X-CSRF-Token"
+	}
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+	exempt := make(map[string]bool, len(config.Exempt))
+	for _, path := range config.Exempt {
+		exempt[path] = true
+	}
+	trusted := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		trusted[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			codec, err := c.Session.DefaultCookieE()
+			if config.Cookie != "" {
+				codec, err = c.Session.CookieE(config.Cookie)
+			}
+			if err != nil {
+				c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(err))
+				return
+			}
+
+			var token []byte
+			if cookie, err := c.Request.Cookie(config.CookieName); err == nil {
+				var encoded string
+				if codec.Decode(config.CookieName, cookie.Value, &encoded) == nil {
+					token, _ = base64.RawURLEncoding.DecodeString(encoded)
+				}
+			}
+			if len(token) != csrfTokenLength {
+				token = make([]byte, csrfTokenLength)
+				_, _ = rand.Read(token)
+				value, err := codec.Encode(config.CookieName, base64.RawURLEncoding.EncodeToString(token))
+				if err != nil {
+					c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(err))
+					return
+				}
+				http.SetCookie(c.Response, &http.Cookie{
+					Name:     config.CookieName,
+					Value:    value,
+					Path:     config.CookiePath,
+					Domain:   config.CookieDomain,
+					MaxAge:   config.CookieMaxAge,
+					HttpOnly: true,
+					Secure:   !config.InsecureCookie,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			c.csrfToken = maskCSRFToken(token)
+			addVary(c.Response.Header(), "Cookie")
+
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next(c)
+				return
+			}
+			if exempt[c.Request.URL.Path] || exempt[RouteTemplate(c.Request)] || (config.ExemptFunc != nil && config.ExemptFunc(c)) {
+				next(c)
+				return
+			}
+
+			if !csrfSameOrigin(c.Request, c.Scheme(), c.Host(), trusted) {
+				c.Error(NewHTTPError(http.StatusForbidden, "cross-origin request rejected").WithCode("csrf_failed").Wrap(ErrCSRFOriginMismatch))
+				return
+			}
+			sent := c.Request.Header.Get(config.Header)
+			if sent == "" {
+				sent = c.Request.PostFormValue(config.FormField)
+			}
+			if sent == "" {
+				c.Error(NewHTTPError(http.StatusForbidden, "CSRF token missing").WithCode("csrf_failed").Wrap(ErrCSRFTokenMissing))
+				return
+			}
+			if subtle.ConstantTimeCompare(unmaskCSRFToken(sent), token) != 1 {
+				c.Error(NewHTTPError(http.StatusForbidden, "CSRF token invalid").WithCode("csrf_failed").Wrap(ErrCSRFTokenInvalid))
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// CSRFToken returns the masked CSRF token to send back in the CSRF header or form field,
+// or an empty string when the CSRF middleware is not used.
+func (c *Context) CSRFToken() string {
+	return c.csrfToken
+}
+
+// csrfSameOrigin reports whether an unsafe request comes from the origin of the request, given by
+// scheme and host, or a trusted origin. The Origin header is checked when present, otherwise Sec-Fetch-Site must not be cross-site.
+// Requests with neither header, such as those from non-browser clients, are allowed and rely on the token.
+func csrfSameOrigin(r *http.Request, scheme, host string, trusted map[string]bool) bool {
+	switch origin := r.Header.Get("Origin"); {
+	case origin == "null":
+		return false
+	case origin != "":
+		if trusted[strings.ToLower(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, host)
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+		return true
+	}
+	return false
+}
+
+// maskCSRFToken returns the token XORed with a random pad, prefixed by the pad, so the
+// token sent in responses changes on every request and cannot be recovered by BREACH attacks.
+func maskCSRFToken(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	pad := masked[:len(token)]
+	_, _ = rand.Read(pad)
+	for i := range token {
+		masked[len(token)+i] = pad[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// unmaskCSRFToken reverses maskCSRFToken. It returns nil for malformed tokens.
+func unmaskCSRFToken(sent string) []byte {
+	masked, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil || len(masked) != 2*csrfTokenLength {
+		return nil
+	}
+	token := make([]byte, csrfTokenLength)
+	for i := range token {
+		token[i] = masked[i] ^ masked[csrfTokenLength+i]
+	}
+	return token
+}
@@ -0,0 +1,170 @@
+package way
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+)
+
+func csrfServer(t *testing.T, config CSRFConfig) (*Way, *error) {
+	t.Helper()
+	w := New()
+	w.sessions.SetDefaultCookie(securecookie.New([]byte("0123456789abcdef0123456789abcdef"), []byte("0123456789abcdef0123456789abcdef")))
+	var handled error
+	w.SetErrorHandler(func(c *Context, err error) {
+		handled = err
+		DefaultErrorHandler(c, err)
+	})
+	w.Use(CSRF(config))
+	w.GET("/form", func(c *Context) {
+		c.String(http.StatusOK, c.CSRFToken())
+	})
+	w.POST("/form", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	w.POST("/webhooks/{provider}", func(c *Context) {
+		c.Status(http.StatusAccepted)
+	})
+	return w, &handled
+}
+
+// csrfSession fetches the form and returns the token cookie and masked token.
+func csrfSession(t *testing.T, w *Way) (*http.Cookie, string) {
+	t.Helper()
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "_csrf" || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("cookies = %+v, want a secure HttpOnly _csrf cookie", cookies)
+	}
+	if rec.Body.Len() == 0 {
+		t.Fatal("CSRFToken() is empty")
+	}
+	return cookies[0], rec.Body.String()
+}
+
+func TestCSRFAcceptsHeaderAndFormTokens(t *testing.T) {
+	w, _ := csrfServer(t, CSRFConfig{})
+	cookie, token := csrfSession(t, w)
+
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("X-CSRF-Token", token)
+	req.Header.Set("Origin", "http://example.com")
+	rec := serve(t, w, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("header token status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body.String())
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Fatal("a valid cookie was replaced")
+	}
+
+	form := url.Values{"csrf_token": {token}}
+	req = httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(cookie)
+	rec = serve(t, w, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("form token status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body.String())
+	}
+}
+
+func TestCSRFTokensAreMaskedPerRequest(t *testing.T) {
+	w, _ := csrfServer(t, CSRFConfig{})
+	cookie, first := csrfSession(t, w)
+
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	req.AddCookie(cookie)
+	rec := serve(t, w, req)
+	second := rec.Body.String()
+
+	if first == second {
+		t.Fatal("CSRFToken() is the same on every request")
+	}
+	if string(unmaskCSRFToken(first)) != string(unmaskCSRFToken(second)) {
+		t.Fatal("masked tokens do not share the cookie token")
+	}
+	if rec.Header().Get("Vary") != "Cookie" {
+		t.Fatalf("Vary = %q, want Cookie", rec.Header().Get("Vary"))
+	}
+}
+
+func TestCSRFRejectsForgedRequests(t *testing.T) {
+	w, handled := csrfServer(t, CSRFConfig{TrustedOrigins: []string{"https://admin.example.com"}})
+	cookie, token := csrfSession(t, w)
+	_, otherToken := csrfSession(t, w)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		cookie  bool
+		want    error
+		status  int
+	}{
+		{name: "missing token", cookie: true, want: ErrCSRFTokenMissing, status: http.StatusForbidden},
+		{name: "token without cookie", headers: map[string]string{"X-CSRF-Token": token}, want: ErrCSRFTokenInvalid, status: http.StatusForbidden},
+		{name: "token of another cookie", cookie: true, headers: map[string]string{"X-CSRF-Token": otherToken}, want: ErrCSRFTokenInvalid, status: http.StatusForbidden},
+		{name: "malformed token", cookie: true, headers: map[string]string{"X-CSRF-Token": "not-a-token"}, want: ErrCSRFTokenInvalid, status: http.StatusForbidden},
+		{name: "cross origin", cookie: true, headers: map[string]string{"X-CSRF-Token": token, "Origin": "https://evil.example"}, want: ErrCSRFOriginMismatch, status: http.StatusForbidden},
+		{name: "other scheme", cookie: true, headers: map[string]string{"X-CSRF-Token": token, "Origin": "https://example.com"}, want: ErrCSRFOriginMismatch, status: http.StatusForbidden},
+		{name: "null origin", cookie: true, headers: map[string]string{"X-CSRF-Token": token, "Origin": "null"}, want: ErrCSRFOriginMismatch, status: http.StatusForbidden},
+		{name: "cross site fetch", cookie: true, headers: map[string]string{"X-CSRF-Token": token, "Sec-Fetch-Site": "cross-site"}, want: ErrCSRFOriginMismatch, status: http.StatusForbidden},
+		{name: "trusted origin", cookie: true, headers: map[string]string{"X-CSRF-Token": token, "Origin": "https://admin.example.com"}, status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*handled = nil
+			req := httptest.NewRequest(http.MethodPost, "/form", nil)
+			if tt.cookie {
+				req.AddCookie(cookie)
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := serve(t, w, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.want != nil && !errors.Is(*handled, tt.want) {
+				t.Fatalf("error = %v, want %v", *handled, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFExemptRoutes(t *testing.T) {
+	w, _ := csrfServer(t, CSRFConfig{
+		Exempt:     []string{"/webhooks/{provider}"},
+		ExemptFunc: func(c *Context) bool { return c.Request.Header.Get("Authorization") != "" },
+	})
+
+	rec := serve(t, w, httptest.NewRequest(http.MethodPost, "/webhooks/stripe", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("exempt route status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = serve(t, w, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("ExemptFunc status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestCSRFWithoutCodec(t *testing.T) {
+	w := New()
+	w.SetSession(NewSession())
+	w.Use(CSRF(CSRFConfig{}))
+	w.GET("/", func(c *Context) {
+		t.Error("handler called without a cookie codec")
+	})
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
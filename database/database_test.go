@@ -1,8 +1,10 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"log/slog"
 	"strings"
 	"testing"
 
@@ -71,3 +73,38 @@ func TestSQLiteSQLHelpersWithOptionalDriver(t *testing.T) {
 		t.Fatalf("name = %q, want Ada", name)
 	}
 }
+
+func TestSQLHelpersLogToContextLogger(t *testing.T) {
+	db, err := SQLConnect("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("SQLConnect() error = %v", err)
+	}
+	defer db.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := ContextWithLogger(context.Background(), logger.With("driver", "sqlite3"))
+	if _, err := SQLExec(db, ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("SQLExec(create) error = %v", err)
+	}
+	if _, err := SQLQuery(db, ctx, "SELECT missing FROM users"); err == nil {
+		t.Fatal("SQLQuery() error = nil, want missing column error")
+	}
+
+	for _, want := range []string{"sql statement executed", "level=ERROR msg=\"sql query failed\"", "driver=sqlite3"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("logs %q do not contain %q", logs.String(), want)
+		}
+	}
+}
+
+func TestLoggerFromContextDefaultsToPackageLogger(t *testing.T) {
+	var logs bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer SetLogger(nil)
+
+	LoggerFromContext(context.Background()).Info("hello")
+	if !strings.Contains(logs.String(), "msg=hello") {
+		t.Fatalf("logs = %q, want package logger output", logs.String())
+	}
+}
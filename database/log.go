@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// loggerKey is the context key for the logger set with ContextWithLogger.
+type loggerKey struct{}
+
+// defaultLogger is the logger used when the context carries none. It discards records until SetLogger is called.
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	defaultLogger.Store(slog.New(slog.DiscardHandler))
+}
+
+// SetLogger sets the package logger used by SQLConnect and PGXConnect, and by the query helpers
+// when the context carries no logger. A nil logger discards log records, which is the default.
+func SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	defaultLogger.Store(logger)
+}
+
+// ContextWithLogger returns a copy of ctx that carries logger.
+// The query helpers log to it instead of the package logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the logger carried by ctx, or the package logger.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return defaultLogger.Load()
+}
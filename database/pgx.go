@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -17,7 +18,7 @@ func PGXConnect(dsn string) (*pgx.Conn, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	log.Println("Successfully connected to the database")
+	defaultLogger.Load().Info("connected to database", slog.String("driver", "pgx"))
 	return conn, nil
 }
 
@@ -39,13 +40,14 @@ func PGXExec(db *pgx.Conn, ctx context.Context, query string, args ...interface{
 	if db == nil {
 		return pgconn.CommandTag{}, errors.New("database connection is not initialized")
 	}
-	log.Printf("Executing PGX statement")
+	logger := LoggerFromContext(ctx)
+	start := time.Now()
 	tag, err := db.Exec(ctx, query, args...)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
+		logger.Error("pgx statement failed", "error", err, "duration", time.Since(start))
 		return pgconn.CommandTag{}, err
 	}
-	log.Printf("PGX statement executed successfully")
+	logger.Debug("pgx statement executed", "rows_affected", tag.RowsAffected(), "duration", time.Since(start))
 	return tag, nil
 }
 
@@ -76,13 +78,14 @@ func PGXQuery(db *pgx.Conn, ctx context.Context, query string, args ...interface
 	if db == nil {
 		return nil, errors.New("database connection is not initialized")
 	}
-	log.Printf("Executing PGX query")
+	logger := LoggerFromContext(ctx)
+	start := time.Now()
 	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
+		logger.Error("pgx query failed", "error", err, "duration", time.Since(start))
 		return nil, err
 	}
-	log.Printf("PGX query executed successfully")
+	logger.Debug("pgx query executed", "duration", time.Since(start))
 	return rows, nil
 }
 
@@ -91,6 +94,6 @@ func PGXQueryRow(db *pgx.Conn, ctx context.Context, query string, args ...interf
 	if db == nil {
 		return nil
 	}
-	log.Printf("Executing PGX query row")
+	LoggerFromContext(ctx).Debug("executing pgx query row")
 	return db.QueryRow(ctx, query, args...)
 }
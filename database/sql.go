@@ -5,7 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 )
 
 // SQLConnect opens a connection to the specified database and checks if it is alive.
@@ -13,19 +14,20 @@ func SQLConnect(driver, dsn string) (*sql.DB, error) {
 	if driver == "" {
 		return nil, errors.New("database driver is not set")
 	}
+	logger := defaultLogger.Load().With(slog.String("driver", driver))
 	db, err := sql.Open(driver, dsn)
 	if err != nil {
-		log.Printf("Failed to open database connection: %v", err)
+		logger.Error("failed to open database connection", "error", err)
 		return nil, fmt.Errorf("open %s database connection: %w; %s", driver, err, DriverImportHint(driver))
 	}
 
 	if err = db.Ping(); err != nil {
-		log.Printf("Failed to ping database: %v", err)
+		logger.Error("failed to ping database", "error", err)
 		db.Close()
 		return nil, fmt.Errorf("ping %s database connection: %w; %s", driver, err, DriverImportHint(driver))
 	}
 
-	log.Printf("Successfully connected to the database with driver %s", driver)
+	logger.Info("connected to database")
 	return db, nil
 }
 
@@ -34,13 +36,14 @@ func SQLExec(db *sql.DB, ctx context.Context, query string, args ...interface{})
 	if db == nil {
 		return nil, errors.New("database connection is not initialized")
 	}
-	log.Printf("Executing SQL statement")
+	logger := LoggerFromContext(ctx)
+	start := time.Now()
 	result, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
+		logger.Error("sql statement failed", "error", err, "duration", time.Since(start))
 		return nil, err
 	}
-	log.Printf("SQL statement executed successfully")
+	logger.Debug("sql statement executed", "duration", time.Since(start))
 	return result, nil
 }
 
@@ -58,13 +61,14 @@ func SQLQuery(db *sql.DB, ctx context.Context, query string, args ...interface{}
 	if db == nil {
 		return nil, errors.New("database connection is not initialized")
 	}
-	log.Printf("Executing SQL query")
+	logger := LoggerFromContext(ctx)
+	start := time.Now()
 	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
+		logger.Error("sql query failed", "error", err, "duration", time.Since(start))
 		return nil, err
 	}
-	log.Printf("SQL query executed successfully")
+	logger.Debug("sql query executed", "duration", time.Since(start))
 	return rows, nil
 }
 
@@ -73,6 +77,6 @@ func SQLQueryRow(db *sql.DB, ctx context.Context, query string, args ...interfac
 	if db == nil {
 		return nil
 	}
-	log.Printf("Executing SQL query row")
+	LoggerFromContext(ctx).Debug("executing sql query row")
 	return db.QueryRowContext(ctx, query, args...)
 }
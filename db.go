@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -21,6 +23,18 @@ type DB struct {
 	MaxOpenConns    int           // For connection pooling configuration
 	MaxIdleConns    int           // For connection pooling configuration
 	ConnMaxLifetime time.Duration // For connection pooling configuration
+	queryObserver   QueryObserver
+}
+
+// QueryObserver is called after every DB exec and query call with the normalized driver name,
+// the SQL operation (the first keyword of the query, e.g. SELECT), the duration and the error, if any.
+// QueryRow calls are reported when the query returns, before the row is scanned, so queries
+// without rows are successful.
+type QueryObserver func(driver, operation string, duration time.Duration, err error)
+
+// SetQueryObserver sets the observer called after exec and query calls, e.g. to record metrics.
+func (d *DB) SetQueryObserver(observer QueryObserver) {
+	d.queryObserver = observer
 }
 
 // New initializes a new DB instance
@@ -148,7 +162,10 @@ func (d *DB) PGXExec(ctx context.Context, query string, args ...interface{}) (pg
 	if d.pgx == nil {
 		return pgconn.CommandTag{}, errors.New("pgx database connection is not initialized")
 	}
-	return database.PGXExec(d.pgx, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	tag, err := database.PGXExec(d.pgx, ctx, query, args...)
+	done(err)
+	return tag, err
 }
 
 // SqlExec executes a sql.DB query
@@ -156,7 +173,10 @@ func (d *DB) SQLExec(ctx context.Context, query string, args ...interface{}) (sq
 	if d.sql == nil {
 		return nil, errors.New("sql database connection is not initialized")
 	}
-	return database.SQLExec(d.sql, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	result, err := database.SQLExec(d.sql, ctx, query, args...)
+	done(err)
+	return result, err
 }
 
 // ExecNoResult executes a query without returning a result
@@ -172,7 +192,7 @@ func (d *DB) PGXExecNoResult(ctx context.Context, query string, args ...interfac
 	if d.pgx == nil {
 		return errors.New("pgx database connection is not initialized")
 	}
-	_, err := database.PGXExec(d.pgx, ctx, query, args...)
+	_, err := d.PGXExec(ctx, query, args...)
 	return err
 }
 
@@ -181,7 +201,7 @@ func (d *DB) SQLExecNoResult(ctx context.Context, query string, args ...interfac
 	if d.sql == nil {
 		return errors.New("sql database connection is not initialized")
 	}
-	_, err := database.SQLExec(d.sql, ctx, query, args...)
+	_, err := d.SQLExec(ctx, query, args...)
 	return err
 }
 
@@ -198,7 +218,10 @@ func (d *DB) PGXQuery(ctx context.Context, query string, args ...interface{}) (p
 	if d.pgx == nil {
 		return nil, errors.New("pgx database connection is not initialized")
 	}
-	return database.PGXQuery(d.pgx, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	rows, err := database.PGXQuery(d.pgx, ctx, query, args...)
+	done(err)
+	return rows, err
 }
 
 // SqlQuery executes a sql.DB query and returns rows
@@ -206,10 +229,14 @@ func (d *DB) SQLQuery(ctx context.Context, query string, args ...interface{}) (*
 	if d.sql == nil {
 		return nil, errors.New("sql database connection is not initialized")
 	}
-	return database.SQLQuery(d.sql, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	rows, err := database.SQLQuery(d.sql, ctx, query, args...)
+	done(err)
+	return rows, err
 }
 
-// QueryRow executes a query that is expected to return at most one row
+// QueryRow executes a query that is expected to return at most one row.
+// See PGXQueryRow and SQLQueryRow for how the call is traced and observed.
 func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) interface{} {
 	if d.UsePgx {
 		return d.PGXQueryRow(ctx, query, args...)
@@ -217,20 +244,32 @@ func (d *DB) QueryRow(ctx context.Context, query string, args ...interface{}) in
 	return d.SQLQueryRow(ctx, query, args...)
 }
 
-// PgxQueryRow executes a pgx query that is expected to return at most one row
+// PgxQueryRow executes a pgx query that is expected to return at most one row.
+// The call is traced and reported to the query observer when it returns, so a row that is
+// never scanned is still reported once. pgx defers query errors to Scan, so the call is
+// reported as successful; a query without rows is successful as well.
 func (d *DB) PGXQueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
 	if d.pgx == nil {
 		return nil
 	}
-	return database.PGXQueryRow(d.pgx, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	row := database.PGXQueryRow(d.pgx, ctx, query, args...)
+	done(nil)
+	return row
 }
 
-// SqlQueryRow executes a sql.DB query that is expected to return at most one row
+// SqlQueryRow executes a sql.DB query that is expected to return at most one row.
+// The call is traced and reported to the query observer when it returns, with the error of
+// the query, so a row that is never scanned is still reported once. A query without rows is
+// successful: sql.ErrNoRows and conversion errors are only returned by Scan.
 func (d *DB) SQLQueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	if d.sql == nil {
 		return nil
 	}
-	return database.SQLQueryRow(d.sql, ctx, query, args...)
+	ctx, done := d.instrument(ctx, query)
+	row := database.SQLQueryRow(d.sql, ctx, query, args...)
+	done(row.Err())
+	return row
 }
 
 // SetDriver sets the database driver
@@ -248,3 +287,26 @@ func (d *DB) SetDriver(driver string, usePgx bool) {
 func (d *DB) SetDSN(driver, dsn, dbName, dbHost, dbPort, dbUser, dbPass string) string {
 	return database.CheckDSN(driver, dsn, dbName, dbHost, dbPort, dbUser, dbPass)
 }
+
+// instrument starts a span for a database call within a traced request and returns the
+// function that ends it and reports the call to the query observer.
+func (d *DB) instrument(ctx context.Context, query string) (context.Context, func(error)) {
+	operation := queryOperation(query)
+	start := time.Now()
+	ctx, span := d.startDBSpan(ctx, operation)
+	return ctx, func(err error) {
+		endSpan(span, err)
+		if d.queryObserver != nil {
+			d.queryObserver(d.Driver, operation, time.Since(start), err)
+		}
+	}
+}
+
+// queryOperation returns the upper-cased first keyword of query.
+func queryOperation(query string) string {
+	operation := strings.TrimSpace(query)
+	if i := strings.IndexFunc(operation, func(r rune) bool { return unicode.IsSpace(r) || r == '(' }); i >= 0 {
+		operation = operation[:i]
+	}
+	return strings.ToUpper(operation)
+}
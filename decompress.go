@@ -0,0 +1,159 @@
+package way
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoder returns a reader that decodes a content coding from r.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// DecompressConfig configures the request decompression middleware.
+//
+// MaxSize limits the size of decompressed request bodies, DefaultMaxBodyBytes when zero.
+// A later BodyLimit replaces it. Decoders adds or replaces content codings by name next to the
+// built-in "gzip", "deflate" and "zstd"; a nil Decoder removes a coding.
+type DecompressConfig struct {
+	MaxSize  int64
+	Decoders map[string]Decoder
+}
+
+// DefaultDecompressConfig is the configuration used by Decompress.
+var DefaultDecompressConfig = DecompressConfig{}
+
+// Decompress returns middleware that decodes gzip, deflate and Zstandard request bodies using
+// DefaultDecompressConfig. See DecompressWithConfig.
+func Decompress() MiddlewareFunc {
+	return DecompressWithConfig(DefaultDecompressConfig)
+}
+
+// DecompressWithConfig returns middleware that decodes request bodies sent with a
+// Content-Encoding, so that handlers and the bind helpers read the decoded body.
+//
+// The Content-Encoding and Content-Length headers are removed from the request. Decoding more
+// than MaxSize bytes returns an *http.MaxBytesError, which is sent as a 413 response, to guard
+// against compression bombs. Requests with an unsupported coding get a 415 response with an
+// Accept-Encoding header listing the supported codings, and bodies that cannot be decoded get
+// a 400 response. Requests with an empty body are passed on unchanged.
+func DecompressWithConfig(config DecompressConfig) MiddlewareFunc {
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxBodyBytes
+	}
+	decoders := map[string]Decoder{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": decodeDeflate,
+		"zstd":    decodeZstd,
+	}
+	for name, decoder := range config.Decoders {
+		if decoder == nil {
+			delete(decoders, strings.ToLower(name))
+			continue
+		}
+		decoders[strings.ToLower(name)] = decoder
+	}
+	names := make([]string, 0, len(decoders))
+	for name := range decoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	accepted := strings.Join(names, ", ")
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			var codings []string
+			for _, value := range c.Request.Header.Values("Content-Encoding") {
+				for _, coding := range strings.Split(value, ",") {
+					if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" && coding != "identity" {
+						codings = append(codings, coding)
+					}
+				}
+			}
+			// An empty body, such as that of a GET request, has nothing to decode.
+			body := c.Request.Body
+			if len(codings) == 0 || c.Request.ContentLength == 0 || body == nil || body == http.NoBody {
+				next(c)
+				return
+			}
+			for _, coding := range codings {
+				if decoders[coding] == nil {
+					c.Response.Header().Set("Accept-Encoding", accepted)
+					c.Error(NewHTTPError(http.StatusUnsupportedMediaType, "content encoding "+coding+" is not supported").WithCode("unsupported_encoding"))
+					return
+				}
+			}
+
+			decoded := &decodedBody{closers: []io.Closer{body}}
+			var r io.Reader = body
+			// Codings are listed in the order they were applied, so decode from the last.
+			for i := len(codings) - 1; i >= 0; i-- {
+				rc, err := decoders[codings[i]](r)
+				if err != nil {
+					decoded.Close()
+					c.Error(bodyError(err))
+					return
+				}
+				decoded.closers = append(decoded.closers, rc)
+				r = rc
+			}
+			decoded.Reader = r
+
+			c.bodyLimiter = &bodyLimiter{body: decoded, limit: config.MaxSize, length: -1}
+			c.Request.Body = c.bodyLimiter
+			c.Request.ContentLength = -1
+			c.Request.Header.Del("Content-Encoding")
+			c.Request.Header.Del("Content-Length")
+			next(c)
+		}
+	}
+}
+
+// decodeZstd decodes the zstd coding. Like browsers, it rejects windows larger than 8 MiB,
+// which bounds the memory a request can make the decoder allocate.
+func decodeZstd(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(8<<20))
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// decodeDeflate decodes the deflate coding, which is zlib data, also accepting the raw
+// deflate data that some clients send instead.
+func decodeDeflate(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodedBody reads a decoded request body and closes its decoders and the original body.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the decoders and the original body.
+func (b *decodedBody) Close() error {
+	var first error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if err := b.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,181 @@
+package way
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type batch struct {
+	Items []string `json:"items"`
+}
+
+func decompressServer(config DecompressConfig) *Way {
+	w := New()
+	w.Use(DecompressWithConfig(config))
+	w.POST("/batch", E(func(c *Context) error {
+		var b batch
+		if err := c.BindJSON(&b); err != nil {
+			return err
+		}
+		c.JSON(http.StatusOK, map[string]int{"items": len(b.Items)})
+		return nil
+	}))
+	return w
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func batchJSON(n int) []byte {
+	body, _ := json.Marshal(batch{Items: strings.Split(strings.Repeat("item,", n-1)+"item", ",")})
+	return body
+}
+
+func postEncoded(encoding string, body []byte) *http.Request {
+	return newRequest(http.MethodPost, "/batch", bytes.NewReader(body), map[string]string{
+		"Content-Type":     "application/json",
+		"Content-Encoding": encoding,
+	})
+}
+
+func TestDecompressGzipBind(t *testing.T) {
+	rec := serve(t, decompressServer(DecompressConfig{}), postEncoded("gzip", gzipBytes(t, batchJSON(1000))))
+
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"items":1000}` {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDecompressSkipsEmptyBodies(t *testing.T) {
+	w := New()
+	w.Use(Decompress())
+	w.GET("/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+	w.POST("/items", E(func(c *Context) error {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		c.String(http.StatusOK, string(body))
+		return nil
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		for _, encoding := range []string{"gzip", "deflate", "zstd"} {
+			rec := serve(t, w, newRequest(method, "/items", nil, map[string]string{"Content-Encoding": encoding}))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s %s status = %d, body = %q", method, encoding, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestDecompressDeflate(t *testing.T) {
+	w := decompressServer(DecompressConfig{})
+
+	var zlibBody bytes.Buffer
+	zw := zlib.NewWriter(&zlibBody)
+	_, _ = zw.Write(batchJSON(3))
+	_ = zw.Close()
+	if rec := serve(t, w, postEncoded("deflate", zlibBody.Bytes())); rec.Code != http.StatusOK {
+		t.Fatalf("zlib status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	var rawBody bytes.Buffer
+	fw, _ := flate.NewWriter(&rawBody, flate.DefaultCompression)
+	_, _ = fw.Write(batchJSON(3))
+	_ = fw.Close()
+	if rec := serve(t, w, postEncoded("deflate", rawBody.Bytes())); rec.Code != http.StatusOK {
+		t.Fatalf("raw deflate status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDecompressZstd(t *testing.T) {
+	w := decompressServer(DecompressConfig{MaxSize: 64 << 10})
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	rec := serve(t, w, postEncoded("zstd", enc.EncodeAll(batchJSON(1000), nil)))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"items":1000}` {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+
+	large, _ := json.Marshal(batch{Items: []string{strings.Repeat("a", 1<<20)}})
+	if rec := serve(t, w, postEncoded("zstd", enc.EncodeAll(large, nil))); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized zstd status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestDecompressLimitsDecodedSize(t *testing.T) {
+	large, _ := json.Marshal(batch{Items: []string{strings.Repeat("a", 1<<20)}})
+	body := gzipBytes(t, large)
+	if len(body) > 4096 {
+		t.Fatalf("compressed body is %d bytes", len(body))
+	}
+
+	rec := serve(t, decompressServer(DecompressConfig{MaxSize: 64 << 10}), postEncoded("gzip", body))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestDecompressUnsupportedAndInvalidBodies(t *testing.T) {
+	w := decompressServer(DecompressConfig{})
+
+	rec := serve(t, w, postEncoded("br", []byte("data")))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+	if got := rec.Header().Get("Accept-Encoding"); got != "deflate, gzip, zstd" {
+		t.Fatalf("Accept-Encoding = %q, want deflate, gzip, zstd", got)
+	}
+
+	if rec := serve(t, w, postEncoded("gzip", []byte("not gzip"))); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid gzip status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	corrupt := gzipBytes(t, batchJSON(100))
+	corrupt[len(corrupt)-5] ^= 0xff
+	if rec := serve(t, w, postEncoded("gzip", corrupt)); rec.Code != http.StatusBadRequest {
+		t.Fatalf("corrupt gzip status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDecompressCustomDecoderAndIdentity(t *testing.T) {
+	w := decompressServer(DecompressConfig{Decoders: map[string]Decoder{
+		"x-gzip": func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	}})
+
+	if rec := serve(t, w, postEncoded("x-gzip", gzipBytes(t, batchJSON(2)))); rec.Code != http.StatusOK {
+		t.Fatalf("x-gzip status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, w, postEncoded("identity", batchJSON(2))); rec.Code != http.StatusOK {
+		t.Fatalf("identity status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, w, postEncoded("gzip, x-gzip", gzipBytes(t, gzipBytes(t, batchJSON(2))))); rec.Code != http.StatusOK {
+		t.Fatalf("stacked codings status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package way
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorHandler handles an error returned by a HandlerFuncE or passed to Context.Error.
+// It is responsible for writing the error response.
+type ErrorHandler func(*Context, error)
+
+// HTTPError is an error with an HTTP status.
+// Status is the HTTP status code of the response.
+// Message is the public message sent to the client.
+// Code is an optional machine-readable error code sent to the client.
+// Err is the internal cause, it is logged but never sent to the client.
+type HTTPError struct {
+	Status  int
+	Message string
+	Code    string
+	Err     error
+}
+
+// NewHTTPError creates a new HTTPError with the given status and public message.
+// If message is empty the standard status text is used.
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: message}
+}
+
+// Error returns the error message including the internal cause.
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap returns the internal cause.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode returns a copy of the error with the given machine-readable code.
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	cp := *e
+	cp.Code = code
+	return &cp
+}
+
+// Wrap returns a copy of the error with the given internal cause.
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// AsHTTPError converts err to an HTTPError.
+// HTTPErrors anywhere in the error chain are returned as is, a Problem keeps its status
+// and detail, ParamErrors map to 400 Bad Request, ValidationErrors map to 422 Unprocessable
+// Entity, SqlErrNoRows and PgxErrNoRows map to 404 Not Found, *http.MaxBytesError maps to
+// 413 Content Too Large, context.DeadlineExceeded maps to 504 Gateway Timeout and any other
+// error maps to 500 Internal Server Error.
+func AsHTTPError(err error) *HTTPError {
+	var httpErr *HTTPError
+	var problem *Problem
+	var validationErrs ValidationErrors
+	var paramErr *ParamError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.As(err, &problem):
+		status := problem.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		return NewHTTPError(status, problem.Detail)
+	case errors.As(err, &paramErr):
+		return paramErr.httpError()
+	case errors.As(err, &validationErrs):
+		return NewHTTPError(http.StatusUnprocessableEntity, "request validation failed").WithCode("validation_failed")
+	case errors.Is(err, SqlErrNoRows), errors.Is(err, PgxErrNoRows):
+		return NewHTTPError(http.StatusNotFound, "").WithCode("not_found").Wrap(err)
+	case errors.As(err, &maxBytesErr):
+		return bodyError(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewHTTPError(http.StatusGatewayTimeout, "request timed out").WithCode("timeout").Wrap(err)
+	default:
+		return NewHTTPError(http.StatusInternalServerError, "").Wrap(err)
+	}
+}
+
+// DefaultErrorHandler is the error handler used when none is set with SetErrorHandler.
+// It logs the internal cause, or the value and stack of a recovered *PanicError, and writes an
+// RFC 9457 application/problem+json response.
+// A *Problem in the error chain is rendered with the request path as its instance and the
+// request ID when it does not set them; other errors are converted with AsHTTPError.
+func DefaultErrorHandler(c *Context, err error) {
+	httpErr := AsHTTPError(err)
+	if httpErr == nil {
+		return
+	}
+	if httpErr.Err != nil || httpErr.Status >= http.StatusInternalServerError {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			c.Slog().Error("recovered from panic", "status", httpErr.Status, "panic", fmt.Sprint(panicErr.Value), "stack", string(panicErr.Stack))
+		} else {
+			c.Slog().Error("request error", "status", httpErr.Status, "error", err)
+		}
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		c.Problem(requestProblem(c, problem))
+		return
+	}
+	c.Problem(problemFromError(c, err))
+}
+
+// Error passes err to the error handler configured on Way,
+// or to DefaultErrorHandler when none is set.
+// When the response has already been written, err is only logged.
+func (c *Context) Error(err error) {
+	if err == nil {
+		return
+	}
+	if c.Written() {
+		c.Slog().Error("request error after the response was written", "status", c.StatusCode(), "error", err)
+		return
+	}
+	handler := DefaultErrorHandler
+	if c.way != nil && c.way.errorHandler != nil {
+		handler = c.way.errorHandler
+	}
+	if c.timeout != nil {
+		c.timeout.handleError()
+	}
+	handler(c, err)
+}
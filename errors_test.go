@@ -0,0 +1,116 @@
+package way
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorReturningHandlerUsesDefaultErrorHandler(t *testing.T) {
+	w := New()
+	w.GET("/users/{id}", E(func(c *Context) error {
+		return fmt.Errorf("load user: %w", SqlErrNoRows)
+	}))
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Extensions["code"] != "not_found" {
+		t.Fatalf("code = %v, want not_found", body.Extensions["code"])
+	}
+}
+
+func TestHTTPErrorDoesNotExposeInternalCause(t *testing.T) {
+	w := New()
+	w.POST("/orders", E(HandlerFuncE(func(c *Context) error {
+		return NewHTTPError(http.StatusConflict, "order already exists").WithCode("duplicate").Wrap(errors.New("unique constraint orders_pkey"))
+	})))
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Detail != "order already exists" || body.Extensions["code"] != "duplicate" {
+		t.Fatalf("body = %+v, want public message and code only", body)
+	}
+	if strings.Contains(rec.Body.String(), "orders_pkey") {
+		t.Fatalf("body = %s, leaks internal cause", rec.Body.String())
+	}
+}
+
+func TestSetErrorHandlerReceivesHandlerError(t *testing.T) {
+	w := New()
+	wantErr := errors.New("boom")
+	var gotErr error
+	w.SetErrorHandler(func(c *Context, err error) {
+		gotErr = err
+		c.Status(http.StatusTeapot)
+	})
+	g := w.Group("/api")
+	g.GET("/fail", E(func(c *Context) error {
+		return wantErr
+	}))
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/fail", nil))
+
+	if !errors.Is(gotErr, wantErr) {
+		t.Fatalf("error handler got %v, want %v", gotErr, wantErr)
+	}
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestErrorAfterResponseIsWrittenIsOnlyLogged(t *testing.T) {
+	w := New()
+	called := false
+	w.SetErrorHandler(func(c *Context, err error) {
+		called = true
+		DefaultErrorHandler(c, err)
+	})
+	w.GET("/x", E(func(c *Context) error {
+		c.JSON(http.StatusOK, map[string]string{"a": "b"})
+		return errors.New("late failure")
+	}))
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x", nil))
+
+	if called {
+		t.Fatal("error handler was called after the response was written")
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"a":"b"}`+"\n" {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAsHTTPErrorDefaultsToInternalServerError(t *testing.T) {
+	httpErr := AsHTTPError(errors.New("database down"))
+	if httpErr.Status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", httpErr.Status, http.StatusInternalServerError)
+	}
+	if httpErr.Message != http.StatusText(http.StatusInternalServerError) {
+		t.Fatalf("message = %q, want status text", httpErr.Message)
+	}
+	if AsHTTPError(nil) != nil {
+		t.Fatal("AsHTTPError(nil) != nil")
+	}
+}
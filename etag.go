@@ -0,0 +1,284 @@
+package way
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swayedev/way/crypto"
+)
+
+// ErrPreconditionFailed is wrapped by the error Context.Precondition returns when the
+// If-Match or If-Unmodified-Since request header does not match the current resource.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ETagConfig configures the ETag middleware.
+// Weak makes ETags weak, for responses whose bytes may change without their meaning changing.
+type ETagConfig struct {
+	Weak bool
+}
+
+// ETag returns middleware that enables strong ETags. See ETagWithConfig.
+func ETag() MiddlewareFunc {
+	return ETagWithConfig(ETagConfig{})
+}
+
+// ETagWithConfig returns middleware that enables ETags for the JSON, Data and HTML helpers and
+// for Context.File and Context.FileFS.
+//
+// The helpers set an ETag computed from the body of 200 responses, unless the handler already set
+// one, and send 304 Not Modified to GET and HEAD requests whose If-None-Match, or If-Modified-Since
+// together with a Last-Modified header set by the handler, shows the client has the current body.
+func ETagWithConfig(config ETagConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.etag = &config
+			next(c)
+		}
+	}
+}
+
+// NewETag returns a strong or weak ETag for data, quoted as it is sent in the ETag header.
+// It is computed with crypto.HashByte.
+func NewETag(data []byte, weak bool) string {
+	return formatETag(crypto.HashByte(data), weak)
+}
+
+// formatETag returns the quoted ETag for the hash of a representation.
+func formatETag(sum [32]byte, weak bool) string {
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+// NotModified sets the ETag and Last-Modified response headers, when etag is not empty and
+// modified is not zero, and reports whether the If-None-Match or If-Modified-Since request
+// header shows the client has the current representation. In that case a 304 Not Modified
+// response has been sent and the handler should return.
+// Only GET and HEAD requests can be answered with 304.
+//
+//	if c.NotModified(article.ETag, article.UpdatedAt) {
+//		return
+//	}
+func (c *Context) NotModified(etag string, modified time.Time) bool {
+	header := c.Response.Header()
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	fresh := false
+	if inm := c.Request.Header.Get("If-None-Match"); inm != "" {
+		fresh = etag != "" && etagListMatch(inm, etag, false)
+	} else if since, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since")); err == nil && !modified.IsZero() {
+		fresh = !modified.Truncate(time.Second).After(since)
+	}
+	if !fresh {
+		return false
+	}
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	c.Response.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// Precondition checks the If-Match and If-Unmodified-Since request headers against the current
+// ETag and modification time of the resource, before a write that must not overwrite changes
+// made by someone else. It returns a 412 *HTTPError wrapping ErrPreconditionFailed if they do not
+// match. An empty etag means the resource does not exist, and a zero modified time is not checked.
+//
+//	if err := c.Precondition(order.ETag(), order.UpdatedAt); err != nil {
+//		return err
+//	}
+func (c *Context) Precondition(etag string, modified time.Time) error {
+	ok := true
+	if im := c.Request.Header.Get("If-Match"); im != "" {
+		ok = etag != "" && etagListMatch(im, etag, true)
+	} else if since, err := http.ParseTime(c.Request.Header.Get("If-Unmodified-Since")); err == nil && !modified.IsZero() {
+		ok = !modified.Truncate(time.Second).After(since)
+	}
+	if ok {
+		return nil
+	}
+	return NewHTTPError(http.StatusPreconditionFailed, "the resource has been modified").WithCode("precondition_failed").Wrap(ErrPreconditionFailed)
+}
+
+// etagListMatch reports whether etag is in an If-Match or If-None-Match list, or the list is "*".
+// The strong comparison does not match weak ETags; the weak comparison ignores the W/ prefix.
+func etagListMatch(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range splitQuoted(list, ',') {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModifiedBody sets the ETag of a 200 response body when the ETag middleware is enabled
+// and reports whether a 304 Not Modified response was sent instead.
+func (c *Context) notModifiedBody(code int, body []byte) bool {
+	if c.etag == nil || code != http.StatusOK {
+		return false
+	}
+	header := c.Response.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		etag = NewETag(body, c.etag.Weak)
+	}
+	modified, _ := http.ParseTime(header.Get("Last-Modified"))
+	return c.NotModified(etag, modified)
+}
+
+// File serves the file at path with http.ServeContent, which sets the Content-Type from the
+// file name, supports Range requests and honours the conditional request headers using the
+// modification time of the file and the ETag set by the ETag middleware. A strong ETag is
+// computed from the file content, which is hashed once per file size and modification time,
+// and a weak one from its size and modification time.
+// Missing files and directories are passed to the error handler as 404 errors.
+//
+// path must not come from the request; use FileFS with os.DirFS to serve files by name.
+func (c *Context) File(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		c.fileError(err)
+		return
+	}
+	defer f.Close()
+	c.serveFile(nil, path, f)
+}
+
+// FileFS serves the file name from fsys like File. fs.FS rejects names that leave its root,
+// so name may come from the request.
+func (c *Context) FileFS(fsys fs.FS, name string) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		c.fileError(err)
+		return
+	}
+	defer f.Close()
+	c.serveFile(fsys, name, f)
+}
+
+// serveFile serves an open file, name in fsys or the path of an operating system file when
+// fsys is nil.
+func (c *Context) serveFile(fsys fs.FS, name string, f fs.File) {
+	info, err := f.Stat()
+	if err != nil {
+		c.fileError(err)
+		return
+	}
+	if info.IsDir() {
+		c.fileError(fs.ErrNotExist)
+		return
+	}
+
+	content, seekable := f.(io.ReadSeeker)
+	if !seekable {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			c.fileError(err)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	if c.etag != nil && c.Response.Header().Get("ETag") == "" {
+		etag := `W/"` + strconv.FormatInt(info.Size(), 16) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + `"`
+		if !c.etag.Weak {
+			if etag, err = fileETag(fsys, name, info, content); err != nil {
+				c.fileError(err)
+				return
+			}
+		}
+		c.Response.Header().Set("ETag", etag)
+	}
+	http.ServeContent(c.Response, c.Request, info.Name(), info.ModTime(), content)
+}
+
+// maxFileETags is the number of strong file ETags kept in fileETags.
+const maxFileETags = 1024
+
+// fileVersion identifies a version of a file by its size and modification time.
+type fileVersion struct {
+	fsys    fs.FS
+	name    string
+	size    int64
+	modTime int64
+}
+
+// fileETags caches the strong ETags of files, so that a file is hashed once per version
+// rather than on every request.
+var fileETags = struct {
+	sync.Mutex
+	m map[fileVersion]string
+}{m: make(map[fileVersion]string)}
+
+// fileETag returns the strong ETag of a file, which NewETag would compute from its content.
+// The content is hashed with crypto.HashReader as it is read and then rewound, and the ETag is cached by file name,
+// size and modification time.
+func fileETag(fsys fs.FS, name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fileVersion{fsys: fsys, name: name, size: info.Size(), modTime: info.ModTime().UnixNano()}
+	cacheable := fsys == nil || reflect.ValueOf(fsys).Comparable()
+	if cacheable {
+		fileETags.Lock()
+		etag, ok := fileETags.m[key]
+		fileETags.Unlock()
+		if ok {
+			return etag, nil
+		}
+	}
+
+	sum, err := crypto.HashReader(content)
+	if err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := formatETag(sum, false)
+
+	if cacheable {
+		fileETags.Lock()
+		if len(fileETags.m) >= maxFileETags {
+			clear(fileETags.m)
+		}
+		fileETags.m[key] = etag
+		fileETags.Unlock()
+	}
+	return etag, nil
+}
+
+// fileError passes an error opening or reading a file to the error handler.
+func (c *Context) fileError(err error) {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+		c.Error(NewHTTPError(http.StatusNotFound, "").WithCode("not_found").Wrap(err))
+		return
+	}
+	c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(err))
+}
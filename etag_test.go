@@ -0,0 +1,206 @@
+package way
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestETagJSONNotModified(t *testing.T) {
+	w := New()
+	w.Use(ETag())
+	w.GET("/articles/1", func(c *Context) {
+		c.JSON(http.StatusOK, map[string]string{"title": "Hello"})
+	})
+
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/articles/1", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("response = %d, ETag %q", rec.Code, etag)
+	}
+
+	rec = serve(t, w, newRequest(http.MethodGet, "/articles/1", nil, map[string]string{"If-None-Match": `"other", W/` + etag}))
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+		t.Fatalf("conditional response = %d %q, ETag %q", rec.Code, rec.Body.String(), rec.Header().Get("ETag"))
+	}
+	if rec.Header().Get("Content-Type") != "" {
+		t.Fatalf("304 Content-Type = %q", rec.Header().Get("Content-Type"))
+	}
+
+	rec = serve(t, w, newRequest(http.MethodGet, "/articles/1", nil, map[string]string{"If-None-Match": `"other"`}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("changed response status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestETagWeakAndLastModified(t *testing.T) {
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	w := New()
+	w.Use(ETagWithConfig(ETagConfig{Weak: true}))
+	w.GET("/page", func(c *Context) {
+		c.SetHeader("Last-Modified", modified.Format(http.TimeFormat))
+		c.HTML(http.StatusOK, "<p>page</p>")
+	})
+	w.GET("/data", func(c *Context) {
+		c.Data(http.StatusOK, []byte("data"))
+	})
+
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/data", nil))
+	if got := rec.Header().Get("ETag"); got != NewETag([]byte("data"), true) || !strings.HasPrefix(got, `W/"`) {
+		t.Fatalf("ETag = %q", got)
+	}
+
+	rec = serve(t, w, newRequest(http.MethodGet, "/page", nil, map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}))
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	rec = serve(t, w, newRequest(http.MethodGet, "/page", nil, map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("stale If-Modified-Since status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestETagDisabledByDefault(t *testing.T) {
+	w := New()
+	w.GET("/", func(c *Context) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	rec := serve(t, w, newRequest(http.MethodGet, "/", nil, map[string]string{"If-None-Match": "*"}))
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Fatalf("response = %d, ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestPrecondition(t *testing.T) {
+	modified := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	current := `"v2"`
+	w := New()
+	w.PUT("/orders/1", E(func(c *Context) error {
+		if err := c.Precondition(current, modified); err != nil {
+			return err
+		}
+		c.Status(http.StatusNoContent)
+		return nil
+	}))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "no preconditions", want: http.StatusNoContent},
+		{name: "current etag", headers: map[string]string{"If-Match": `"v1", "v2"`}, want: http.StatusNoContent},
+		{name: "stale etag", headers: map[string]string{"If-Match": `"v1"`}, want: http.StatusPreconditionFailed},
+		{name: "weak etag", headers: map[string]string{"If-Match": `W/"v2"`}, want: http.StatusPreconditionFailed},
+		{name: "any", headers: map[string]string{"If-Match": "*"}, want: http.StatusNoContent},
+		{name: "unmodified", headers: map[string]string{"If-Unmodified-Since": modified.Format(http.TimeFormat)}, want: http.StatusNoContent},
+		{name: "modified", headers: map[string]string{"If-Unmodified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, want: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(t, w, newRequest(http.MethodPut, "/orders/1", nil, tt.headers)); rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	c := proxyContext(t, nil, "192.0.2.1:1234", map[string]string{"If-Match": "*"})
+	if err := c.Precondition("", time.Time{}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("Precondition() for a missing resource = %v, want ErrPreconditionFailed", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(path, []byte("quarterly report"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w := New()
+	w.GET("/report", func(c *Context) {
+		c.File(path)
+	}, ETag())
+	w.GET("/missing", func(c *Context) {
+		c.File(filepath.Join(dir, "missing.txt"))
+	})
+
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "quarterly report" {
+		t.Fatalf("response = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != NewETag([]byte("quarterly report"), false) {
+		t.Fatalf("ETag = %q", etag)
+	}
+
+	if rec := serve(t, w, newRequest(http.MethodGet, "/report", nil, map[string]string{"If-None-Match": etag})); rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec := serve(t, w, newRequest(http.MethodGet, "/report", nil, map[string]string{"Range": "bytes=0-8"})); rec.Code != http.StatusPartialContent || rec.Body.String() != "quarterly" {
+		t.Fatalf("range response = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/missing", nil)); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing file status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// The ETag is cached by size and modification time, and a new version is hashed again.
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("quarterly budget"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(t, w, httptest.NewRequest(http.MethodGet, "/report", nil)).Header().Get("ETag"); got != etag {
+		t.Fatalf("cached ETag = %q, want %q", got, etag)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(t, w, httptest.NewRequest(http.MethodGet, "/report", nil)).Header().Get("ETag"); got != NewETag([]byte("quarterly budget"), false) {
+		t.Fatalf("ETag of the new version = %q", got)
+	}
+}
+
+func TestFileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"static/app.css": {Data: []byte("body{}"), ModTime: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	w := New()
+	w.GET("/assets/{name:.*}", func(c *Context) {
+		c.FileFS(fsys, "static/"+c.Parm("name"))
+	}, ETagWithConfig(ETagConfig{Weak: true}))
+
+	rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/assets/app.css", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "body{}" || !strings.HasPrefix(rec.Header().Get("ETag"), `W/"6-`) {
+		t.Fatalf("response = %d %q, ETag %q", rec.Code, rec.Body.String(), rec.Header().Get("ETag"))
+	}
+	if rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/assets/../static/app.css", nil)); rec.Code == http.StatusOK {
+		t.Fatal("path outside the file system was served")
+	}
+	if rec := serve(t, w, httptest.NewRequest(http.MethodGet, "/assets/", nil)); rec.Code != http.StatusNotFound {
+		t.Fatalf("directory status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// MapFS cannot be a cache key, so its files are hashed on every request.
+	w.GET("/strong/{name:.*}", func(c *Context) {
+		c.FileFS(fsys, "static/"+c.Parm("name"))
+	}, ETag())
+	rec = serve(t, w, newRequest(http.MethodGet, "/strong/app.css", nil, map[string]string{"Range": "bytes=0-3"}))
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "body" || rec.Header().Get("ETag") != NewETag([]byte("body{}"), false) {
+		t.Fatalf("response = %d %q, ETag %q", rec.Code, rec.Body.String(), rec.Header().Get("ETag"))
+	}
+}
@@ -3,11 +3,13 @@ module github.com/swayedev/way
 go 1.26.0
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/go-sql-driver/mysql v1.10.0
 	github.com/gorilla/mux v1.8.1
 	github.com/gorilla/securecookie v1.1.2
 	github.com/gorilla/sessions v1.4.0
 	github.com/jackc/pgx/v5 v5.9.2
+	github.com/klauspost/compress v1.20.1
 	github.com/swayedev/fcrypt v1.0.0-rc1
 	github.com/mattn/go-sqlite3 v1.14.44
 	golang.org/x/crypto v0.51.0
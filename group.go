@@ -0,0 +1,87 @@
+package way
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// Group is a set of routes that share a path prefix and middleware.
+// Groups are backed by a gorilla/mux subrouter, so group middleware only
+// runs for requests that match a route registered on the group.
+type Group struct {
+	// way is the Way instance the group belongs to.
+	way *Way
+	// router is the subrouter that holds the group's routes.
+	router *mux.Router
+	// prefix is the full path prefix of the group, including parent groups.
+	prefix string
+}
+
+// Group creates a new route group for the given path prefix.
+// Middleware passed to Group runs after the global middleware added with Use
+// and only for routes registered on the group or its nested groups.
+func (w *Way) Group(prefix string, middleware ...MiddlewareFunc) *Group {
+	return newGroup(w, w.router, prefix, prefix, middleware)
+}
+
+// newGroup creates a group on the given parent router.
+func newGroup(w *Way, parent *mux.Router, prefix, fullPrefix string, middleware []MiddlewareFunc) *Group {
+	g := &Group{
+		way:    w,
+		router: parent.PathPrefix(prefix).Subrouter(),
+		prefix: fullPrefix,
+	}
+	g.Use(middleware...)
+	return g
+}
+
+// Group creates a nested route group below the current group.
+// The nested group inherits the path prefix and middleware of its parents.
+func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
+	return newGroup(g.way, g.router, prefix, g.prefix+prefix, middleware)
+}
+
+// Prefix returns the full path prefix of the group.
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+// Use adds middleware to the group's middleware stack.
+func (g *Group) Use(middleware ...MiddlewareFunc) {
+	g.way.use(g.router, middleware...)
+}
+
+// HandleFunc registers a new route on the group with a matcher for the URL path.
+// Wrap a HandlerFuncE with E to register it.
+// Optional middleware only runs for this route, after the global and group middleware.
+func (g *Group) HandleFunc(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.way.handle(g.router, g.prefix+path, path, handler, middleware)
+}
+
+// handleFuncWithMethod registers a new route on the group with a matcher for the URL path and the HTTP method.
+func (g *Group) handleFuncWithMethod(path string, handler HandlerFunc, middleware []MiddlewareFunc, method string) {
+	g.way.handle(g.router, g.prefix+path, path, handler, middleware, method)
+}
+
+// HTTP method shortcuts
+// Each shortcut accepts optional route middleware, see Way.GET.
+func (g *Group) GET(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "GET")
+}
+func (g *Group) POST(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "POST")
+}
+func (g *Group) PUT(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "PUT")
+}
+func (g *Group) DELETE(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "DELETE")
+}
+func (g *Group) PATCH(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "PATCH")
+}
+func (g *Group) OPTIONS(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "OPTIONS")
+}
+func (g *Group) HEAD(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.handleFuncWithMethod(path, handler, middleware, "HEAD")
+}
@@ -0,0 +1,102 @@
+package way
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGroupRegistersRoutesWithPrefix(t *testing.T) {
+	w := New()
+	api := w.Group("/api")
+	api.GET("/users", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestGroupMiddlewareOnlyRunsForGroupRoutes(t *testing.T) {
+	w := New()
+	var calls []string
+	w.GET("/public", func(c *Context) {
+		calls = append(calls, "public")
+	})
+	admin := w.Group("/admin", func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			calls = append(calls, "admin-mw")
+			next(c)
+		}
+	})
+	admin.GET("/dashboard", func(c *Context) {
+		calls = append(calls, "dashboard")
+	})
+
+	for _, path := range []string{"/public", "/admin/dashboard"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w.router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := strings.Join(calls, ","); got != "public,admin-mw,dashboard" {
+		t.Fatalf("calls = %q, want group middleware only around group route", got)
+	}
+}
+
+func TestNestedGroupsComposePrefixAndMiddleware(t *testing.T) {
+	w := New()
+	var calls []string
+	record := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) {
+				calls = append(calls, name)
+				next(c)
+			}
+		}
+	}
+	w.Use(record("global"))
+	api := w.Group("/api", record("api"))
+	v1 := api.Group("/v1")
+	v1.Use(record("v1"))
+	v1.POST("/items", func(c *Context) {
+		calls = append(calls, "handler")
+		c.Status(http.StatusCreated)
+	})
+
+	if v1.Prefix() != "/api/v1" {
+		t.Fatalf("Prefix() = %q, want /api/v1", v1.Prefix())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/items", nil)
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := strings.Join(calls, ","); got != "global,api,v1,handler" {
+		t.Fatalf("calls = %q, want global,api,v1,handler", got)
+	}
+}
+
+func TestGroupMethodMismatchIsNotMatched(t *testing.T) {
+	w := New()
+	g := w.Group("/api")
+	g.GET("/items", func(c *Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/items", nil)
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,27 @@
+package way
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRequest returns a test request for target with the given headers; empty values are not set.
+func newRequest(method, target string, body io.Reader, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return req
+}
+
+// serve serves req with h and returns the recorded response.
+func serve(t *testing.T, h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
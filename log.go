@@ -0,0 +1,117 @@
+package way
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/swayedev/way/trace"
+)
+
+// SetSlog sets the structured logger.
+// Way.Logger is replaced with a *log.Logger that writes to the same handler at info level,
+// so code that still uses Log() ends up in the same place.
+func (w *Way) SetSlog(logger *slog.Logger) {
+	if logger == nil {
+		logger = defaultSlogger()
+	}
+	w.slogger = logger
+	w.Logger = slog.NewLogLogger(logger.Handler(), slog.LevelInfo)
+	w.slogBridge = w.Logger
+}
+
+// Slog returns the structured logger.
+// If Way.Logger was set with SetLogger or assigned directly, Slog writes through it,
+// filtered by the WAY_LOG_LEVEL environment variable.
+func (w *Way) Slog() *slog.Logger {
+	if w.slogger != nil && w.Logger == w.slogBridge {
+		return w.slogger
+	}
+	return slog.New(newLogLoggerHandler(w.Log(), logLevel()))
+}
+
+// Slog returns the structured logger with the request method, path, and the
+// request ID and trace ID, when present, as attributes.
+func (c *Context) Slog() *slog.Logger {
+	logger := c.slogger
+	if logger == nil {
+		logger = slog.New(newLogLoggerHandler(c.Log(), logLevel()))
+	}
+	return logger.With(c.requestAttrs()...)
+}
+
+// requestAttrs returns the log attributes identifying the request and, when traced, its span.
+func (c *Context) requestAttrs() []any {
+	if c.Request == nil {
+		return nil
+	}
+	attrs := []any{slog.String("method", c.Request.Method), slog.String("path", c.Request.URL.Path)}
+	if id := c.RequestID(); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+	}
+	return attrs
+}
+
+// defaultSlogger returns the logger used when none is set.
+// It writes text, or JSON when WAY_LOG_FORMAT is "json", to os.Stdout at the level set by WAY_LOG_LEVEL.
+// WAY_DEFAULT_LOGGER, when set, is added to every record as the logger attribute.
+func defaultSlogger() *slog.Logger {
+	return slog.New(defaultSlogHandler(os.Stdout))
+}
+
+// defaultSlogHandler returns the default handler writing to out.
+func defaultSlogHandler(out io.Writer) slog.Handler {
+	options := &slog.HandlerOptions{Level: logLevel()}
+	var handler slog.Handler
+	if strings.EqualFold(GetEnv(envLogFormat, ""), "json") {
+		handler = slog.NewJSONHandler(out, options)
+	} else {
+		handler = slog.NewTextHandler(out, options)
+	}
+	if name := GetEnv(envDefaultLogger, ""); name != "" {
+		handler = handler.WithAttrs([]slog.Attr{slog.String("logger", name)})
+	}
+	return handler
+}
+
+// logLevel returns the level set by WAY_LOG_LEVEL: debug, info, warn or error. It defaults to info.
+func logLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(GetEnv(envLogLevel, "info"))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// newLogLoggerHandler returns a handler that writes each record as one line of logger,
+// leaving timestamps to the logger's own flags.
+func newLogLoggerHandler(logger *log.Logger, level slog.Leveler) slog.Handler {
+	return slog.NewTextHandler(logLoggerWriter{logger: logger}, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+// logLoggerWriter writes to a *log.Logger.
+type logLoggerWriter struct {
+	logger *log.Logger
+}
+
+// Write writes p, a single formatted record, to the logger.
+func (w logLoggerWriter) Write(p []byte) (int, error) {
+	if err := w.logger.Output(2, string(bytes.TrimSuffix(p, []byte("\n")))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,93 @@
+package way
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetSlogAddsRequestAttributes(t *testing.T) {
+	var logs bytes.Buffer
+	w := New()
+	w.SetSlog(slog.New(slog.NewJSONHandler(&logs, nil)))
+	w.Use(RequestID(RequestIDConfig{}))
+	w.GET("/users", func(c *Context) {
+		c.Slog().Info("listing users", "count", 2)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	w.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("decode log record %q: %v", logs.String(), err)
+	}
+	want := map[string]interface{}{"msg": "listing users", "method": "GET", "path": "/users", "request_id": "req-1", "count": float64(2)}
+	for key, value := range want {
+		if record[key] != value {
+			t.Fatalf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
+
+func TestSetSlogBridgesLogLogger(t *testing.T) {
+	var logs bytes.Buffer
+	w := New()
+	w.SetSlog(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	w.Log().Printf("legacy %s", "message")
+	if !strings.Contains(logs.String(), `msg="legacy message"`) {
+		t.Fatalf("logs = %q, want bridged record", logs.String())
+	}
+}
+
+func TestSetLoggerAdaptsStructuredRecords(t *testing.T) {
+	t.Setenv(envLogLevel, "warn")
+	var logs bytes.Buffer
+	w := New()
+	w.SetLogger(log.New(&logs, "app: ", 0))
+
+	w.Slog().Info("dropped")
+	w.Slog().Warn("slow query", "duration_ms", 120)
+
+	if got, want := logs.String(), "app: level=WARN msg=\"slow query\" duration_ms=120\n"; got != want {
+		t.Fatalf("logs = %q, want %q", got, want)
+	}
+}
+
+func TestLoggingMiddlewareRecordsStatusAndBytes(t *testing.T) {
+	var logs bytes.Buffer
+	w := New()
+	w.SetSlog(slog.New(slog.NewTextHandler(&logs, nil)))
+	w.Use(RequestID(RequestIDConfig{}))
+	w.POST("/orders", func(c *Context) {
+		c.String(http.StatusCreated, "created")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("X-Request-ID", "req-2")
+	w.loggingMiddleware(w.router).ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, want := range []string{"msg=request", "method=POST", "path=/orders", "request_id=req-2", "status=201", "bytes=7", "duration="} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("logs %q do not contain %q", logs.String(), want)
+		}
+	}
+}
+
+func TestLogLevelFromEnvironment(t *testing.T) {
+	t.Setenv(envLogLevel, "debug")
+	if got := logLevel(); got != slog.LevelDebug {
+		t.Fatalf("logLevel() = %v, want debug", got)
+	}
+	t.Setenv(envLogLevel, "verbose")
+	if got := logLevel(); got != slog.LevelInfo {
+		t.Fatalf("logLevel() = %v, want info for unknown level", got)
+	}
+}
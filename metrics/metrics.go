@@ -0,0 +1,164 @@
+// Package metrics exposes Prometheus text-format metrics for Way applications
+// without depending on the Prometheus client library.
+//
+//	m := metrics.New()
+//	w.Use(m.Middleware())
+//	db.SetQueryObserver(m.ObserveQuery)
+//	m.CollectDBStats("main", db.SQL())
+//	sessions.SetObserver(m.ObserveSessionStore)
+//	w.GET("/metrics", m.Serve)
+//
+// HTTP metrics are labelled with the gorilla/mux route template, such as /users/{id},
+// rather than the raw path, so the number of series stays bounded. Query results follow
+// way.QueryObserver: QueryRow calls are counted when the query returns, so a query without
+// rows is ok.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swayedev/way"
+)
+
+// Metrics holds the Way HTTP, database and session metrics.
+type Metrics struct {
+	registry *Registry
+
+	requests        *Counter
+	requestDuration *Histogram
+	inFlight        *Gauge
+	responseSize    *Histogram
+
+	dbQueries       *Counter
+	dbQueryDuration *Histogram
+
+	dbOpen              *Gauge
+	dbInUse             *Gauge
+	dbIdle              *Gauge
+	dbMaxOpen           *Gauge
+	dbWaitCount         *Counter
+	dbWaitDuration      *Counter
+	dbMaxIdleClosed     *Counter
+	dbMaxIdleTimeClosed *Counter
+	dbMaxLifetimeClosed *Counter
+
+	sessionLookups *Counter
+}
+
+// New returns Metrics registered in a new Registry.
+func New() *Metrics {
+	return NewWithRegistry(NewRegistry())
+}
+
+// NewWithRegistry returns Metrics registered in registry, so applications can add their own metrics
+// to the same endpoint. It panics if the Way metric names are already registered.
+func NewWithRegistry(registry *Registry) *Metrics {
+	return &Metrics{
+		registry: registry,
+
+		requests:        registry.NewCounter("way_http_requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		requestDuration: registry.NewHistogram("way_http_request_duration_seconds", "HTTP request latency in seconds.", DefaultBuckets, "method", "route"),
+		inFlight:        registry.NewGauge("way_http_requests_in_flight", "Number of HTTP requests being served."),
+		responseSize:    registry.NewHistogram("way_http_response_size_bytes", "HTTP response body size in bytes.", SizeBuckets, "method", "route"),
+
+		dbQueries:       registry.NewCounter("way_db_queries_total", "Total number of database calls.", "driver", "operation", "result"),
+		dbQueryDuration: registry.NewHistogram("way_db_query_duration_seconds", "Database call latency in seconds.", DefaultBuckets, "driver", "operation"),
+
+		dbOpen:              registry.NewGauge("way_db_open_connections", "Number of established database connections.", "db"),
+		dbInUse:             registry.NewGauge("way_db_in_use_connections", "Number of database connections in use.", "db"),
+		dbIdle:              registry.NewGauge("way_db_idle_connections", "Number of idle database connections.", "db"),
+		dbMaxOpen:           registry.NewGauge("way_db_max_open_connections", "Maximum number of open database connections.", "db"),
+		dbWaitCount:         registry.NewCounter("way_db_wait_count_total", "Total number of waits for a database connection.", "db"),
+		dbWaitDuration:      registry.NewCounter("way_db_wait_duration_seconds_total", "Total time waited for database connections.", "db"),
+		dbMaxIdleClosed:     registry.NewCounter("way_db_max_idle_closed_total", "Connections closed because of SetMaxIdleConns.", "db"),
+		dbMaxIdleTimeClosed: registry.NewCounter("way_db_max_idle_time_closed_total", "Connections closed because of SetConnMaxIdleTime.", "db"),
+		dbMaxLifetimeClosed: registry.NewCounter("way_db_max_lifetime_closed_total", "Connections closed because of SetConnMaxLifetime.", "db"),
+
+		sessionLookups: registry.NewCounter("way_session_store_lookups_total", "Total number of session store lookups.", "store", "result"),
+	}
+}
+
+// Registry returns the registry the metrics are registered in.
+func (m *Metrics) Registry() *Registry {
+	return m.registry
+}
+
+// Handler returns an http.Handler serving the metrics, for use on a separate server.
+func (m *Metrics) Handler() http.Handler {
+	return m.registry.Handler()
+}
+
+// Serve is a way.HandlerFunc serving the metrics.
+func (m *Metrics) Serve(c *way.Context) {
+	m.registry.Handler().ServeHTTP(c.Response, c.Request)
+}
+
+// Middleware returns middleware recording request counts, latencies, response sizes
+// and in-flight requests. Register it with Use; requests that match no route are only counted
+// as in flight, since they have no route template to be labelled with.
+func (m *Metrics) Middleware() way.MiddlewareFunc {
+	return func(next way.HandlerFunc) way.HandlerFunc {
+		return func(c *way.Context) {
+			start := time.Now()
+			m.inFlight.Inc()
+			defer m.inFlight.Dec()
+
+			next(c)
+
+			route := way.RouteTemplate(c.Request)
+			if route == "" {
+				return
+			}
+			status := c.StatusCode()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			method := c.Request.Method
+			m.requests.Inc(method, route, strconv.Itoa(status))
+			m.requestDuration.Observe(time.Since(start).Seconds(), method, route)
+			m.responseSize.Observe(float64(c.BytesWritten()), method, route)
+		}
+	}
+}
+
+// ObserveQuery records a database call. It is a way.QueryObserver for DB.SetQueryObserver.
+func (m *Metrics) ObserveQuery(driver, operation string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.dbQueries.Inc(driver, operation, result)
+	m.dbQueryDuration.Observe(duration.Seconds(), driver, operation)
+}
+
+// ObserveSessionStore records a session store lookup. It is a way.SessionObserver for Session.SetObserver.
+func (m *Metrics) ObserveSessionStore(store string, hit bool) {
+	result := "hit"
+	if !hit {
+		result = "miss"
+	}
+	m.sessionLookups.Inc(store, result)
+}
+
+// CollectDBStats reports the connection pool statistics of db, labelled with name, on every scrape.
+// It does nothing for a nil db, such as the sql.DB of a pgx connection.
+func (m *Metrics) CollectDBStats(name string, db *sql.DB) {
+	if db == nil {
+		return
+	}
+	m.registry.OnScrape(func() {
+		stats := db.Stats()
+		m.dbOpen.Set(float64(stats.OpenConnections), name)
+		m.dbInUse.Set(float64(stats.InUse), name)
+		m.dbIdle.Set(float64(stats.Idle), name)
+		m.dbMaxOpen.Set(float64(stats.MaxOpenConnections), name)
+		m.dbWaitCount.set(float64(stats.WaitCount), name)
+		m.dbWaitDuration.set(stats.WaitDuration.Seconds(), name)
+		m.dbMaxIdleClosed.set(float64(stats.MaxIdleClosed), name)
+		m.dbMaxIdleTimeClosed.set(float64(stats.MaxIdleTimeClosed), name)
+		m.dbMaxLifetimeClosed.set(float64(stats.MaxLifetimeClosed), name)
+	})
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/swayedev/way"
+	_ "github.com/swayedev/way/database/drivers/sqlite"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, ContentType)
+	}
+	return rec.Body.String()
+}
+
+func assertContains(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	m := New()
+	w := way.New()
+	w.Use(m.Middleware())
+	w.GET("/users/{id}", func(c *way.Context) {
+		c.String(http.StatusOK, "user")
+	})
+	w.GET("/metrics", m.Serve)
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		w.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assertContains(t, rec.Body.String(),
+		"# TYPE way_http_requests_total counter",
+		`way_http_requests_total{method="GET",route="/users/{id}",status="200"} 2`,
+		`way_http_request_duration_seconds_count{method="GET",route="/users/{id}"} 2`,
+		`way_http_response_size_bytes_bucket{method="GET",route="/users/{id}",le="100"} 2`,
+		`way_http_response_size_bytes_sum{method="GET",route="/users/{id}"} 8`,
+		"way_http_requests_in_flight 1",
+	)
+	if strings.Contains(rec.Body.String(), "/users/1") {
+		t.Fatal("metrics are labelled with the raw path")
+	}
+}
+
+func TestMiddlewareSkipsUnmatchedRequests(t *testing.T) {
+	m := New()
+	w := way.New()
+	w.Pre(m.Middleware())
+	w.GET("/users/{id}", func(c *way.Context) {
+		c.String(http.StatusOK, "user")
+	})
+
+	w.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if body := scrape(t, m); strings.Contains(body, "way_http_requests_total{") {
+		t.Fatalf("unmatched request was recorded:\n%s", body)
+	}
+}
+
+func TestObserveQueryAndDBStats(t *testing.T) {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(3)
+	db := way.NewDB()
+	db.SQLNew(conn, "sqlite3")
+
+	m := New()
+	db.SetQueryObserver(m.ObserveQuery)
+	m.CollectDBStats("main", db.SQL())
+
+	ctx := httptest.NewRequest(http.MethodGet, "/", nil).Context()
+	if _, err := db.SQLExec(ctx, "CREATE TABLE users (id INTEGER)"); err != nil {
+		t.Fatalf("SQLExec() error = %v", err)
+	}
+	rows, err := db.SQLQuery(ctx, "select id from users")
+	if err != nil {
+		t.Fatalf("SQLQuery() error = %v", err)
+	}
+	rows.Close()
+	if _, err := db.SQLQuery(ctx, "SELECT missing FROM users"); err == nil {
+		t.Fatal("SQLQuery() error = nil, want missing column error")
+	}
+	var id int
+	if err := db.SQLQueryRow(ctx, "SELECT missing FROM users").Scan(&id); err == nil {
+		t.Fatal("SQLQueryRow() error = nil, want missing column error")
+	}
+
+	assertContains(t, scrape(t, m),
+		`way_db_queries_total{driver="sqlite3",operation="CREATE",result="ok"} 1`,
+		`way_db_queries_total{driver="sqlite3",operation="SELECT",result="error"} 2`,
+		`way_db_queries_total{driver="sqlite3",operation="SELECT",result="ok"} 1`,
+		`way_db_query_duration_seconds_count{driver="sqlite3",operation="SELECT"} 3`,
+		`way_db_max_open_connections{db="main"} 3`,
+		"# TYPE way_db_wait_count_total counter",
+	)
+}
+
+func TestObserveSessionStore(t *testing.T) {
+	m := New()
+	s := way.NewSession()
+	s.SetStore("default", sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef")))
+	s.SetObserver(m.ObserveSessionStore)
+
+	s.Store("default")
+	s.DefaultSession()
+	if _, err := s.StoreE("missing"); err == nil {
+		t.Fatal("StoreE() error = nil for a missing store")
+	}
+
+	assertContains(t, scrape(t, m),
+		`way_session_store_lookups_total{store="default",result="hit"} 2`,
+		`way_session_store_lookups_total{store="missing",result="miss"} 1`,
+	)
+}
+
+func TestRegistryEscapesAndSortsOutput(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("b_total", "Help with \\ and\nnewline.", "label")
+	g := r.NewGauge("a_gauge", "A gauge.")
+	h := r.NewHistogram("c_seconds", "A histogram.", []float64{1, 0.5})
+	c.Add(2, "quote \" backslash \\ newline \n")
+	g.Set(-1.5)
+	h.Observe(0.75)
+	h.Observe(2)
+
+	var out strings.Builder
+	buf := bufio.NewWriter(&out)
+	r.WriteTo(buf)
+	buf.Flush()
+
+	want := `# HELP a_gauge A gauge.
+# TYPE a_gauge gauge
+a_gauge -1.5
+# HELP b_total Help with \\ and\nnewline.
+# TYPE b_total counter
+b_total{label="quote \" backslash \\ newline \n"} 2
+# HELP c_seconds A histogram.
+# TYPE c_seconds histogram
+c_seconds_bucket{le="0.5"} 0
+c_seconds_bucket{le="1"} 1
+c_seconds_bucket{le="+Inf"} 2
+c_seconds_sum 2.75
+c_seconds_count 2
+`
+	if out.String() != want {
+		t.Fatalf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegistryPanicsOnMisuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("requests_total", "Requests.", "method")
+	for name, fn := range map[string]func(){
+		"duplicate":   func() { r.NewGauge("requests_total", "Again.") },
+		"label count": func() { c.Inc() },
+		"negative":    func() { c.Add(-1, "GET") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestObserveQueryRecordsErrors(t *testing.T) {
+	m := New()
+	m.ObserveQuery("pgx", "UPDATE", 20*time.Millisecond, errors.New("deadlock"))
+	assertContains(t, scrape(t, m),
+		`way_db_queries_total{driver="pgx",operation="UPDATE",result="error"} 1`,
+		`way_db_query_duration_seconds_bucket{driver="pgx",operation="UPDATE",le="0.025"} 1`,
+	)
+}
@@ -0,0 +1,216 @@
+package way
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Media types of the built-in renderers used by Context.Negotiate.
+const (
+	MIMEJSON = "application/json"
+	MIMEXML  = "application/xml"
+	MIMEText = "text/plain"
+	MIMEHTML = "text/html"
+)
+
+// Renderer writes data as a response with the given status code and its media type,
+// setting the Content-Type header. It is used by Context.Negotiate.
+// A renderer that cannot represent data returns an error wrapping ErrUnsupportedData
+// without writing, so that Negotiate tries the next acceptable media type.
+type Renderer func(c *Context, code int, data interface{}) error
+
+// ErrUnsupportedData is wrapped by the errors of renderers that cannot represent the data.
+var ErrUnsupportedData = errors.New("data not supported by the renderer")
+
+// mediaRenderer is a Renderer registered for a media type.
+type mediaRenderer struct {
+	mediaType string
+	render    Renderer
+}
+
+// defaultRenderers are the built-in renderers, in the order Negotiate offers them.
+var defaultRenderers = []mediaRenderer{
+	{mediaType: MIMEJSON, render: func(c *Context, code int, data interface{}) error {
+		c.JSON(code, data)
+		return nil
+	}},
+	{mediaType: MIMEXML, render: func(c *Context, code int, data interface{}) error {
+		c.XML(code, data)
+		return nil
+	}},
+	{mediaType: MIMEText, render: func(c *Context, code int, data interface{}) error {
+		switch v := data.(type) {
+		case string, []byte:
+			c.String(code, v)
+		default:
+			c.String(code, fmt.Sprint(v))
+		}
+		return nil
+	}},
+	{mediaType: MIMEHTML, render: func(c *Context, code int, data interface{}) error {
+		switch v := data.(type) {
+		case template.HTML:
+			c.HTML(code, string(v))
+		case string:
+			c.HTML(code, v)
+		case []byte:
+			c.HTML(code, string(v))
+		default:
+			return fmt.Errorf("way: cannot render %T as HTML: %w", data, ErrUnsupportedData)
+		}
+		return nil
+	}},
+}
+
+// RegisterRenderer registers renderer for mediaType, such as "text/csv", for Context.Negotiate.
+// It replaces the renderer registered for the same media type, including the built-in JSON,
+// XML, plain text and HTML renderers. Renderers should be registered before the server starts.
+// It panics if mediaType is not a valid media type or renderer is nil.
+func (w *Way) RegisterRenderer(mediaType string, renderer Renderer) {
+	parsed, _, err := mime.ParseMediaType(mediaType)
+	if err != nil || renderer == nil || !strings.Contains(parsed, "/") || strings.Contains(parsed, "*") {
+		panic(fmt.Sprintf("way: invalid renderer for %q", mediaType))
+	}
+	if w.renderers == nil {
+		w.renderers = append([]mediaRenderer(nil), defaultRenderers...)
+	}
+	for i := range w.renderers {
+		if w.renderers[i].mediaType == parsed {
+			w.renderers[i].render = renderer
+			return
+		}
+	}
+	w.renderers = append(w.renderers, mediaRenderer{mediaType: parsed, render: renderer})
+}
+
+// Negotiate writes data with the renderer for the media type in offers that the Accept request
+// header prefers, using q-values and the most specific matching media range. Offers default to
+// every registered media type, JSON, XML, plain text and HTML first. When several offers are
+// preferred equally, or there is no Accept header, the first one is used. When a renderer
+// returns ErrUnsupportedData, such as the HTML renderer for data other than strings, the next
+// acceptable offer is used.
+//
+// When the client accepts none of the offers, or none of their renderers supports data, a 406
+// *HTTPError with the code "not_acceptable" is passed to the error handler. Other renderer errors
+// and offers without a renderer are passed to it as 500 errors. The Vary: Accept header is
+// always set.
+//
+//	c.Negotiate(http.StatusOK, orders, way.MIMEJSON, "text/csv")
+func (c *Context) Negotiate(code int, data interface{}, offers ...string) {
+	renderers := defaultRenderers
+	if c.way != nil && c.way.renderers != nil {
+		renderers = c.way.renderers
+	}
+	if len(offers) == 0 {
+		offers = make([]string, len(renderers))
+		for i, r := range renderers {
+			offers[i] = r.mediaType
+		}
+	}
+	addVary(c.Response.Header(), "Accept")
+
+	for _, offer := range negotiateMediaTypes(c.Request.Header.Values("Accept"), offers) {
+		render := rendererFor(renderers, offer)
+		if render == nil {
+			c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(fmt.Errorf("way: no renderer registered for %q", offer)))
+			return
+		}
+		err := render(c, code, data)
+		if errors.Is(err, ErrUnsupportedData) && !c.Written() {
+			continue
+		}
+		if err != nil {
+			c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(err))
+		}
+		return
+	}
+	c.Error(NewHTTPError(http.StatusNotAcceptable, "none of the available media types are acceptable: "+strings.Join(offers, ", ")).WithCode("not_acceptable"))
+}
+
+// rendererFor returns the renderer registered for mediaType, or nil.
+func rendererFor(renderers []mediaRenderer, mediaType string) Renderer {
+	for _, r := range renderers {
+		if strings.EqualFold(r.mediaType, mediaType) {
+			return r.render
+		}
+	}
+	return nil
+}
+
+// acceptRange is a media range of an Accept header.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// negotiateMediaTypes returns the offers the Accept header values accept, most preferred first
+// and in offer order when preferred equally. Every offer is returned, in order, when there is
+// no valid Accept header.
+func negotiateMediaTypes(values []string, offers []string) []string {
+	var ranges []acceptRange
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			mediaRange, params, _ := strings.Cut(entry, ";")
+			typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+			if !ok || typ == "" || subtype == "" || typ == "*" && subtype != "*" {
+				continue
+			}
+			q := 1.0
+			for _, param := range strings.Split(params, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+					if parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+						q = parsed
+					} else {
+						q = 0
+					}
+				}
+			}
+			ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+		}
+	}
+	if len(ranges) == 0 {
+		return offers
+	}
+
+	type rankedOffer struct {
+		offer string
+		q     float64
+	}
+	var accepted []rankedOffer
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, rankedOffer{offer: offer, q: q})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].q > accepted[j].q
+	})
+	preferred := make([]string, len(accepted))
+	for i, a := range accepted {
+		preferred[i] = a.offer
+	}
+	return preferred
+}
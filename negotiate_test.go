@@ -0,0 +1,133 @@
+package way
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type negotiateItem struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (i negotiateItem) String() string {
+	return "item " + i.Name
+}
+
+// browserAccept is the Accept header browsers send for page navigations.
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+
+func negotiateServer() *Way {
+	w := New()
+	w.RegisterRenderer("text/csv; charset=utf-8", func(c *Context, code int, data interface{}) error {
+		c.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		c.Response.WriteHeader(code)
+		cw := csv.NewWriter(c.Response)
+		_ = cw.Write([]string{"name", data.(negotiateItem).Name})
+		cw.Flush()
+		return cw.Error()
+	})
+	w.GET("/item", func(c *Context) {
+		c.Negotiate(http.StatusOK, negotiateItem{Name: "way"})
+	})
+	w.GET("/json-or-csv", func(c *Context) {
+		c.Negotiate(http.StatusOK, negotiateItem{Name: "way"}, MIMEJSON, "text/csv")
+	})
+	return w
+}
+
+func negotiateGet(path, accept string) *http.Request {
+	return newRequest(http.MethodGet, path, nil, map[string]string{"Accept": accept})
+}
+
+func TestNegotiate(t *testing.T) {
+	w := negotiateServer()
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{name: "no accept", path: "/item", contentType: "application/json", body: `{"name":"way"}`},
+		{name: "xml", path: "/item", accept: "application/xml", contentType: "application/xml", body: "<negotiateItem><name>way</name></negotiateItem>"},
+		{name: "q-values", path: "/item", accept: "application/json;q=0.5, text/plain;q=0.9", contentType: "text/plain", body: "item way"},
+		{name: "specific range wins", path: "/json-or-csv", accept: "text/*;q=0.8, text/plain;q=0.1, application/json;q=0.5", contentType: "text/csv; charset=utf-8"},
+		{name: "wildcard uses offer order", path: "/json-or-csv", accept: "*/*", contentType: "application/json"},
+		{name: "registered renderer", path: "/json-or-csv", accept: "text/csv", contentType: "text/csv; charset=utf-8", body: "name,way"},
+		// The item cannot be rendered as HTML, so the next preferred type is used.
+		{name: "browser accept", path: "/item", accept: browserAccept, contentType: "application/xml", body: "<negotiateItem><name>way</name></negotiateItem>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, w, negotiateGet(tt.path, tt.accept))
+			if tt.status == 0 {
+				tt.status = http.StatusOK
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Fatalf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Fatalf("Vary = %q, want Accept", got)
+			}
+			if tt.body != "" && strings.TrimSpace(rec.Body.String()) != tt.body {
+				t.Fatalf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	rec := serve(t, negotiateServer(), negotiateGet("/json-or-csv", "application/xml, text/*;q=0"))
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotAcceptable)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"not_acceptable"`) {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
+
+func TestNegotiateHTMLString(t *testing.T) {
+	w := New()
+	w.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, "<p>hello</p>", MIMEHTML, MIMEText)
+	})
+
+	rec := serve(t, w, negotiateGet("/", browserAccept))
+	if rec.Header().Get("Content-Type") != "text/html; charset=utf-8" || rec.Body.String() != "<p>hello</p>" {
+		t.Fatalf("response = %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestNegotiateUnsupportedDataIsNotAcceptable(t *testing.T) {
+	w := New()
+	w.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, negotiateItem{Name: "way"}, MIMEHTML)
+	})
+
+	if rec := serve(t, w, negotiateGet("/", browserAccept)); rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNotAcceptable, rec.Body)
+	}
+}
+
+func TestRegisterRendererPanicsOnInvalidMediaType(t *testing.T) {
+	for _, mediaType := range []string{"", "text", "text/*"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterRenderer(%q) did not panic", mediaType)
+				}
+			}()
+			New().RegisterRenderer(mediaType, func(*Context, int, interface{}) error { return nil })
+		}()
+	}
+}
@@ -0,0 +1,184 @@
+package way
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrParamMissing is wrapped by a ParamError when a required parameter is not present.
+var ErrParamMissing = errors.New("parameter is missing")
+
+// ParamError is returned by the typed parameter accessors when a parameter is missing
+// or cannot be parsed. The default error handler renders it as a 400 response.
+// Source is "path" or "query", Name is the parameter name and Value the raw value.
+type ParamError struct {
+	Source string
+	Name   string
+	Value  string
+	Err    error
+}
+
+// Error returns a description of the invalid parameter.
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, ErrParamMissing) {
+		return fmt.Sprintf("%s parameter %q is missing", e.Source, e.Name)
+	}
+	return fmt.Sprintf("invalid %s parameter %q value %q: %v", e.Source, e.Name, e.Value, e.Err)
+}
+
+// Unwrap returns the parse error.
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// httpError converts the parameter error to a 400 HTTPError.
+func (e *ParamError) httpError() *HTTPError {
+	message := fmt.Sprintf("invalid %s parameter %q", e.Source, e.Name)
+	if errors.Is(e.Err, ErrParamMissing) {
+		message = fmt.Sprintf("%s parameter %q is missing", e.Source, e.Name)
+	}
+	return NewHTTPError(http.StatusBadRequest, message).WithCode("invalid_parameter").Wrap(e)
+}
+
+// ParmInt returns the path parameter as an int.
+func (c *Context) ParmInt(param string) (int, error) {
+	value, err := c.ParmInt64(param)
+	if err != nil {
+		return 0, err
+	}
+	if int64(int(value)) != value {
+		return 0, &ParamError{Source: "path", Name: param, Value: c.Parm(param), Err: strconv.ErrRange}
+	}
+	return int(value), nil
+}
+
+// ParmInt64 returns the path parameter as an int64.
+func (c *Context) ParmInt64(param string) (int64, error) {
+	raw, err := c.requiredParm(param)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, &ParamError{Source: "path", Name: param, Value: raw, Err: numError(err)}
+	}
+	return value, nil
+}
+
+// ParmUUID returns the path parameter as a lower-case UUID string in canonical form.
+func (c *Context) ParmUUID(param string) (string, error) {
+	raw, err := c.requiredParm(param)
+	if err != nil {
+		return "", err
+	}
+	if !uuidPattern.MatchString(raw) {
+		return "", &ParamError{Source: "path", Name: param, Value: raw, Err: errors.New("not a valid UUID")}
+	}
+	return strings.ToLower(raw), nil
+}
+
+// requiredParm returns the path parameter or a ParamError if it is missing.
+func (c *Context) requiredParm(param string) (string, error) {
+	raw, ok := c.Parms()[param]
+	if !ok || raw == "" {
+		return "", &ParamError{Source: "path", Name: param, Err: ErrParamMissing}
+	}
+	return raw, nil
+}
+
+// QueryParams returns the parsed URL query parameters.
+func (c *Context) QueryParams() url.Values {
+	return c.Request.URL.Query()
+}
+
+// QueryParam returns the first value of the query parameter, or an empty string if it is not present.
+func (c *Context) QueryParam(name string) string {
+	return c.QueryParams().Get(name)
+}
+
+// QueryDefault returns the first value of the query parameter,
+// or defaultValue if the parameter is not present or empty.
+func (c *Context) QueryDefault(name, defaultValue string) string {
+	if value := c.QueryParam(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// QueryInt returns the query parameter as an int.
+// It returns 0 and no error if the parameter is not present or empty.
+func (c *Context) QueryInt(name string) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &ParamError{Source: "query", Name: name, Value: raw, Err: numError(err)}
+	}
+	return value, nil
+}
+
+// QueryBool returns the query parameter as a bool.
+// It accepts the values understood by strconv.ParseBool and returns false and no error
+// if the parameter is not present or empty.
+func (c *Context) QueryBool(name string) (bool, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, &ParamError{Source: "query", Name: name, Value: raw, Err: errors.New("not a valid boolean")}
+	}
+	return value, nil
+}
+
+// QueryTime returns the query parameter parsed with the given layout, time.RFC3339 when empty.
+// It returns the zero time and no error if the parameter is not present or empty.
+func (c *Context) QueryTime(name, layout string) (time.Time, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	value, err := time.Parse(layout, raw)
+	if err != nil {
+		return time.Time{}, &ParamError{Source: "query", Name: name, Value: raw, Err: fmt.Errorf("not a valid time in layout %q", layout)}
+	}
+	return value, nil
+}
+
+// QuerySlice returns all values of the query parameter.
+// Repeated parameters and comma-separated values are both supported, so
+// ?tag=a&tag=b and ?tag=a,b return [a b]. Empty values are dropped.
+func (c *Context) QuerySlice(name string) []string {
+	var values []string
+	for _, raw := range c.QueryParams()[name] {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// numError returns the underlying error of a strconv.NumError without the repeated input.
+func numError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		if errors.Is(numErr.Err, strconv.ErrRange) {
+			return errors.New("value out of range")
+		}
+		return errors.New("not a valid integer")
+	}
+	return err
+}
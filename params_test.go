@@ -0,0 +1,109 @@
+package way
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// paramContext returns a context for a request routed through a Way route with the given template.
+func paramContext(t *testing.T, template, target string) *Context {
+	t.Helper()
+	w := New()
+	var ctx *Context
+	w.GET(template, func(c *Context) {
+		ctx = c
+	})
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	if ctx == nil {
+		t.Fatalf("route %s did not match %s", template, target)
+	}
+	return ctx
+}
+
+func TestParmTypedAccessors(t *testing.T) {
+	ctx := paramContext(t, "/users/{id}/keys/{key}", "/users/42/keys/3F2504E0-4F89-11D3-9A0C-0305E82C3301")
+
+	if id, err := ctx.ParmInt("id"); err != nil || id != 42 {
+		t.Fatalf("ParmInt() = %d, %v; want 42", id, err)
+	}
+	if id, err := ctx.ParmInt64("id"); err != nil || id != 42 {
+		t.Fatalf("ParmInt64() = %d, %v; want 42", id, err)
+	}
+	if key, err := ctx.ParmUUID("key"); err != nil || key != "3f2504e0-4f89-11d3-9a0c-0305e82c3301" {
+		t.Fatalf("ParmUUID() = %q, %v", key, err)
+	}
+}
+
+func TestParmTypedAccessorErrors(t *testing.T) {
+	ctx := paramContext(t, "/users/{id}", "/users/abc")
+
+	_, err := ctx.ParmInt("id")
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) || paramErr.Source != "path" || paramErr.Value != "abc" {
+		t.Fatalf("ParmInt() error = %v, want path ParamError", err)
+	}
+	if _, err := ctx.ParmUUID("id"); err == nil {
+		t.Fatal("ParmUUID() error = nil, want error")
+	}
+	if _, err := ctx.ParmInt64("missing"); !errors.Is(err, ErrParamMissing) {
+		t.Fatalf("ParmInt64(missing) error = %v, want ErrParamMissing", err)
+	}
+}
+
+func TestQueryAccessors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?q=go&page=2&draft=true&tag=a,b&tag=c&since=2026-03-04", nil)
+	ctx := NewContext(httptest.NewRecorder(), req, nil, nil, nil)
+
+	if got := ctx.QueryParam("q"); got != "go" {
+		t.Fatalf("QueryParam() = %q, want go", got)
+	}
+	if got := ctx.QueryDefault("sort", "name"); got != "name" {
+		t.Fatalf("QueryDefault() = %q, want name", got)
+	}
+	if got, err := ctx.QueryInt("page"); err != nil || got != 2 {
+		t.Fatalf("QueryInt() = %d, %v", got, err)
+	}
+	if got, err := ctx.QueryBool("draft"); err != nil || !got {
+		t.Fatalf("QueryBool() = %v, %v", got, err)
+	}
+	if got, err := ctx.QueryBool("missing"); err != nil || got {
+		t.Fatalf("QueryBool(missing) = %v, %v", got, err)
+	}
+	if got := ctx.QuerySlice("tag"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("QuerySlice() = %v, want [a b c]", got)
+	}
+	since, err := ctx.QueryTime("since", time.DateOnly)
+	if err != nil || !since.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("QueryTime() = %v, %v", since, err)
+	}
+	if len(ctx.QueryParams()) != 5 {
+		t.Fatalf("QueryParams() = %v", ctx.QueryParams())
+	}
+}
+
+func TestParamErrorRendersBadRequest(t *testing.T) {
+	w := New()
+	w.GET("/orders/{id}", E(func(c *Context) error {
+		_, err := c.ParmInt64("id")
+		return err
+	}))
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/12x", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if p.Detail != `invalid path parameter "id"` || p.Extensions["code"] != "invalid_parameter" {
+		t.Fatalf("problem = %+v", p)
+	}
+}
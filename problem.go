@@ -0,0 +1,177 @@
+package way
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// MIMEProblemJSON is the media type of RFC 9457 problem details documents.
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem is an RFC 9457 problem details document.
+// Type is a URI reference identifying the problem type, "about:blank" when empty.
+// Title is a short summary of the problem type, the status text when empty.
+// Status is the HTTP status code.
+// Detail is an explanation specific to this occurrence of the problem.
+// Instance is a URI reference identifying this occurrence of the problem.
+// Extensions holds additional members, such as validation errors or a request ID.
+//
+// Problem implements error, so handlers may return it directly.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// problemMembers are the members defined by RFC 9457 that extensions may not override.
+var problemMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// NewProblem creates a new problem with the given status and detail.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With sets an extension member and returns the problem.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// Error returns the title and detail of the problem.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// MarshalJSON encodes the problem with extension members at the top level.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		if !problemMembers[key] {
+			doc[key] = value
+		}
+	}
+	doc["type"] = p.Type
+	if p.Type == "" {
+		doc["type"] = "about:blank"
+	}
+	if p.Title != "" {
+		doc["title"] = p.Title
+	}
+	if p.Status != 0 {
+		doc["status"] = p.Status
+	}
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a problem document, collecting unknown members into Extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*p = Problem{}
+	fields := map[string]interface{}{
+		"type":     &p.Type,
+		"title":    &p.Title,
+		"status":   &p.Status,
+		"detail":   &p.Detail,
+		"instance": &p.Instance,
+	}
+	for key, raw := range doc {
+		if field, ok := fields[key]; ok {
+			if err := json.Unmarshal(raw, field); err != nil {
+				return fmt.Errorf("problem member %q: %w", key, err)
+			}
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		p.With(key, value)
+	}
+	return nil
+}
+
+// Problem writes p as an application/problem+json response.
+// The response status is p.Status, or 500 when it is not set.
+func (c *Context) Problem(p *Problem) {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	c.writeJSON(status, MIMEProblemJSON, p)
+}
+
+// problemFromError builds the problem document rendered by DefaultErrorHandler.
+func problemFromError(c *Context, err error) *Problem {
+	httpErr := AsHTTPError(err)
+	p := NewProblem(httpErr.Status, "")
+	if httpErr.Message != p.Title {
+		p.Detail = httpErr.Message
+	}
+	if httpErr.Code != "" {
+		p.With("code", httpErr.Code)
+	}
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p.With("errors", validationErrs)
+	}
+	addRequestDetails(c, p)
+	return p
+}
+
+// requestProblem returns a copy of a problem returned by a handler with the request details
+// DefaultErrorHandler adds, leaving the handler's problem unchanged.
+func requestProblem(c *Context, problem *Problem) *Problem {
+	p := *problem
+	if problem.Extensions != nil {
+		p.Extensions = make(map[string]interface{}, len(problem.Extensions)+1)
+		for key, value := range problem.Extensions {
+			p.Extensions[key] = value
+		}
+	}
+	addRequestDetails(c, &p)
+	return &p
+}
+
+// addRequestDetails sets the request ID extension and the instance to the request path
+// when they are not already set.
+func addRequestDetails(c *Context, p *Problem) {
+	if _, ok := p.Extensions["request_id"]; !ok {
+		if id := c.RequestID(); id != "" {
+			p.With("request_id", id)
+		}
+	}
+	if p.Instance == "" && c.Request != nil && c.Request.URL != nil {
+		p.Instance = c.Request.URL.Path
+	}
+}
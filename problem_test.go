@@ -0,0 +1,107 @@
+package way
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextProblemWritesProblemJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil), nil, nil, nil)
+
+	ctx.Problem(NewProblem(http.StatusForbidden, "account is locked").With("balance", 30))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if got := rec.Header().Get("Content-Type"); got != MIMEProblemJSON {
+		t.Fatalf("content type = %q, want %q", got, MIMEProblemJSON)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	want := map[string]interface{}{
+		"type":    "about:blank",
+		"title":   "Forbidden",
+		"status":  float64(http.StatusForbidden),
+		"detail":  "account is locked",
+		"balance": float64(30),
+	}
+	for key, value := range want {
+		if doc[key] != value {
+			t.Fatalf("%s = %v, want %v", key, doc[key], value)
+		}
+	}
+}
+
+func TestProblemExtensionsCannotOverrideMembers(t *testing.T) {
+	p := NewProblem(http.StatusBadRequest, "bad input").With("status", 200).With("title", "OK")
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded Problem
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Status != http.StatusBadRequest || decoded.Title != "Bad Request" {
+		t.Fatalf("decoded = %+v, want extensions ignored for standard members", decoded)
+	}
+}
+
+func TestDefaultErrorHandlerRendersReturnedProblem(t *testing.T) {
+	quota := NewProblem(http.StatusTooManyRequests, "quota exceeded").With("limit", 10)
+	quota.Type = "https://example.com/problems/quota"
+	w := New()
+	w.Use(RequestID(RequestIDConfig{}))
+	w.GET("/quota", E(func(c *Context) error {
+		return quota
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/quota", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if p.Type != "https://example.com/problems/quota" {
+		t.Fatalf("type = %q, want custom problem type", p.Type)
+	}
+	if p.Instance != "/quota" || p.Extensions["request_id"] != "req-7" || p.Extensions["limit"] != float64(10) {
+		t.Fatalf("problem = %+v, want instance, request_id and limit", p)
+	}
+	if quota.Instance != "" || len(quota.Extensions) != 1 {
+		t.Fatalf("returned problem was modified: %+v", quota)
+	}
+}
+
+func TestDefaultErrorHandlerSetsInstance(t *testing.T) {
+	w := New()
+	w.GET("/missing/{id}", E(func(c *Context) error {
+		return PgxErrNoRows
+	}))
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing/7", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != MIMEProblemJSON {
+		t.Fatalf("content type = %q, want %q", got, MIMEProblemJSON)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if p.Status != http.StatusNotFound || p.Instance != "/missing/7" {
+		t.Fatalf("problem = %+v, want 404 for /missing/7", p)
+	}
+}
@@ -0,0 +1,235 @@
+package way
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// SetTrustedProxies sets the addresses of the proxies and load balancers in front of the server,
+// as CIDRs such as "10.0.0.0/8" or single IP addresses. Forwarding headers are only honoured on
+// requests from these addresses, see Context.ClientIP. It replaces any previously set proxies
+// and returns an error if an entry cannot be parsed.
+func (w *Way) SetTrustedProxies(proxies ...string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	w.trustedProxies = prefixes
+	return nil
+}
+
+// ClientIP returns the IP address of the client.
+//
+// When the request comes from a trusted proxy set with SetTrustedProxies, the RFC 7239 Forwarded
+// header, X-Forwarded-For or X-Real-IP, in that order of preference, is read from right to left,
+// skipping trusted proxies, and the first untrusted address is returned. Otherwise, or when the
+// headers hold no valid address, the address of the peer from Request.RemoteAddr is returned.
+func (c *Context) ClientIP() string {
+	return c.clientHop().addr
+}
+
+// Scheme returns the scheme the client used, "http" or "https". Behind a trusted proxy it is read
+// from the Forwarded proto parameter or the X-Forwarded-Proto entry of the hop ClientIP comes from,
+// counted from the right like X-Forwarded-For, otherwise from the TLS connection state.
+func (c *Context) Scheme() string {
+	return c.clientHop().proto
+}
+
+// Host returns the host the client requested. Behind a trusted proxy it is read from the
+// Forwarded host parameter or the X-Forwarded-Host entry of the same hop as Scheme,
+// otherwise it is Request.Host.
+func (c *Context) Host() string {
+	return c.clientHop().host
+}
+
+// forwardedHop is the address, scheme and host of a request received by a server or proxy.
+type forwardedHop struct {
+	addr  string
+	proto string
+	host  string
+}
+
+// clientHop returns the hop of the original client request.
+func (c *Context) clientHop() forwardedHop {
+	r := c.Request
+	peer := forwardedHop{addr: remoteHost(r), proto: "http", host: r.Host}
+	if r.TLS != nil {
+		peer.proto = "https"
+	}
+	if !c.trustedProxy(peer.addr) {
+		return peer
+	}
+
+	hops := parseForwarded(r.Header.Values("Forwarded"))
+	if len(hops) == 0 {
+		for _, addr := range headerList(r.Header.Values("X-Forwarded-For")) {
+			hops = append(hops, forwardedHop{addr: addr})
+		}
+		if len(hops) == 0 && r.Header.Get("X-Real-IP") != "" {
+			hops = append(hops, forwardedHop{addr: r.Header.Get("X-Real-IP")})
+		}
+		// Every proxy appends to X-Forwarded-Proto and X-Forwarded-Host as it does to
+		// X-Forwarded-For, so their entries belong to the hops counted from the right.
+		protos := headerList(r.Header.Values("X-Forwarded-Proto"))
+		hosts := headerList(r.Header.Values("X-Forwarded-Host"))
+		if len(hops) == 0 && (len(protos) > 0 || len(hosts) > 0) {
+			hops = append(hops, forwardedHop{addr: peer.addr})
+		}
+		for k := 1; k <= len(hops); k++ {
+			if k <= len(protos) {
+				hops[len(hops)-k].proto = strings.ToLower(protos[len(protos)-k])
+			}
+			if k <= len(hosts) {
+				hops[len(hops)-k].host = hosts[len(hosts)-k]
+			}
+		}
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseForwardedAddr(hops[i].addr)
+		if !ok {
+			break
+		}
+		client.addr = addr
+		if hops[i].proto == "http" || hops[i].proto == "https" {
+			client.proto = hops[i].proto
+		}
+		if validForwardedHost(hops[i].host) {
+			client.host = hops[i].host
+		}
+		if !c.trustedProxy(addr) {
+			break
+		}
+	}
+	return client
+}
+
+// trustedProxy reports whether addr is in the trusted proxy ranges.
+func (c *Context) trustedProxy(addr string) bool {
+	if c.way == nil || len(c.way.trustedProxies) == 0 {
+		return false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range c.way.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwarded parses RFC 7239 Forwarded header values into hops, from left to right.
+func parseForwarded(values []string) []forwardedHop {
+	var hops []forwardedHop
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var hop forwardedHop
+			for _, pair := range splitQuoted(element, ';') {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "for":
+					hop.addr = val
+				case "proto":
+					hop.proto = strings.ToLower(val)
+				case "host":
+					hop.host = val
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s at sep outside of double-quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseForwardedAddr returns the IP address of a Forwarded for parameter or X-Forwarded-For entry,
+// which may have a port and IPv6 brackets. Obfuscated identifiers and "unknown" are not addresses.
+func parseForwardedAddr(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return "", false
+		}
+		s = s[1:end]
+	} else if strings.Count(s, ":") == 1 {
+		s, _, _ = strings.Cut(s, ":")
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", false
+	}
+	return ip.Unmap().String(), true
+}
+
+// validForwardedHost reports whether a forwarded host looks like a host with an optional port.
+func validForwardedHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, " \t/\\@?#,;\"")
+}
+
+// headerList returns the trimmed entries of comma-separated header values, from left to right.
+func headerList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			list = append(list, strings.TrimSpace(entry))
+		}
+	}
+	return list
+}
+
+// AbsoluteURL returns the absolute URL of path, such as "/login", using the scheme and host the
+// client requested, for redirects and links that must be correct behind proxies.
+func (c *Context) AbsoluteURL(path string) string {
+	hop := c.clientHop()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return hop.proto + "://" + hop.host + path
+}
@@ -0,0 +1,139 @@
+package way
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func proxyContext(t *testing.T, proxies []string, remoteAddr string, headers map[string]string) *Context {
+	t.Helper()
+	w := New()
+	if err := w.SetTrustedProxies(proxies...); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://app.internal/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return w.acquireContext(httptest.NewRecorder(), req)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "2001:db8:ffff::/48", "192.0.2.10"}
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{name: "untrusted peer", remote: "203.0.113.7:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "203.0.113.7"},
+		{name: "no headers", remote: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "x-forwarded-for", remote: "10.0.0.1:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "skips trusted hops", remote: "10.0.0.1:5000", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, 10.1.1.1, 192.0.2.10"}, want: "198.51.100.1"},
+		{name: "ignores spoofed hops", remote: "10.0.0.1:5000", headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.1.1.1"}, want: "198.51.100.1"},
+		{name: "all trusted", remote: "10.0.0.1:5000", headers: map[string]string{"X-Forwarded-For": "10.2.2.2, 10.1.1.1"}, want: "10.2.2.2"},
+		{name: "x-real-ip", remote: "10.0.0.1:5000", headers: map[string]string{"X-Real-IP": "198.51.100.2"}, want: "198.51.100.2"},
+		{name: "invalid entry", remote: "10.0.0.1:5000", headers: map[string]string{"X-Forwarded-For": "garbage"}, want: "10.0.0.1"},
+		{name: "forwarded preferred", remote: "10.0.0.1:5000", headers: map[string]string{
+			"Forwarded":       `for=198.51.100.3;proto=https, for="[2001:db8:ffff::1]:443"`,
+			"X-Forwarded-For": "198.51.100.9",
+		}, want: "198.51.100.3"},
+		{name: "forwarded ipv6 client", remote: "[2001:db8:ffff::2]:443", headers: map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711"`}, want: "2001:db8:cafe::17"},
+		{name: "forwarded with port", remote: "10.0.0.1:5000", headers: map[string]string{"Forwarded": `for="198.51.100.4:8080"`}, want: "198.51.100.4"},
+		{name: "forwarded unknown", remote: "10.0.0.1:5000", headers: map[string]string{"Forwarded": "for=unknown"}, want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := proxyContext(t, proxies, tt.remote, tt.headers).ClientIP(); got != tt.want {
+				t.Fatalf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemeAndHost(t *testing.T) {
+	c := proxyContext(t, nil, "10.0.0.1:5000", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"})
+	if c.Scheme() != "http" || c.Host() != "app.internal" {
+		t.Fatalf("untrusted Scheme(), Host() = %q, %q", c.Scheme(), c.Host())
+	}
+	c.Request.TLS = &tls.ConnectionState{}
+	if c.Scheme() != "https" {
+		t.Fatalf("TLS Scheme() = %q, want https", c.Scheme())
+	}
+
+	c = proxyContext(t, []string{"10.0.0.0/8"}, "10.0.0.1:5000", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "www.example.com"})
+	if c.Scheme() != "https" || c.Host() != "www.example.com" {
+		t.Fatalf("X-Forwarded Scheme(), Host() = %q, %q", c.Scheme(), c.Host())
+	}
+	if got := c.AbsoluteURL("/login"); got != "https://www.example.com/login" {
+		t.Fatalf("AbsoluteURL() = %q", got)
+	}
+
+	c = proxyContext(t, []string{"10.0.0.0/8"}, "10.0.0.1:5000", map[string]string{
+		"Forwarded": `for=198.51.100.3;proto=https;host="shop.example.com", for=10.1.1.1;proto=http;host=app.internal`,
+	})
+	if c.Scheme() != "https" || c.Host() != "shop.example.com" {
+		t.Fatalf("Forwarded Scheme(), Host() = %q, %q", c.Scheme(), c.Host())
+	}
+
+	c = proxyContext(t, []string{"10.0.0.0/8"}, "10.0.0.1:5000", map[string]string{
+		"X-Forwarded-For":   "1.2.3.4",
+		"X-Forwarded-Proto": "http, https",
+		"X-Forwarded-Host":  "evil.example, real.example",
+	})
+	if c.ClientIP() != "1.2.3.4" || c.Scheme() != "https" || c.Host() != "real.example" {
+		t.Fatalf("client supplied entries were used: %q, %q, %q", c.ClientIP(), c.Scheme(), c.Host())
+	}
+
+	c = proxyContext(t, []string{"10.0.0.0/8"}, "10.0.0.1:5000", map[string]string{
+		"X-Forwarded-For":  "198.51.100.1, 10.1.1.1",
+		"X-Forwarded-Host": "shop.example.com, app.internal",
+	})
+	if c.ClientIP() != "198.51.100.1" || c.Host() != "shop.example.com" {
+		t.Fatalf("multi-proxy ClientIP(), Host() = %q, %q", c.ClientIP(), c.Host())
+	}
+
+	c = proxyContext(t, []string{"10.0.0.0/8"}, "10.0.0.1:5000", map[string]string{"X-Forwarded-Proto": "javascript", "X-Forwarded-Host": "a.com/evil"})
+	if c.Scheme() != "http" || c.Host() != "app.internal" {
+		t.Fatalf("invalid forwarded values were used: %q, %q", c.Scheme(), c.Host())
+	}
+}
+
+func TestSetTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	w := New()
+	for _, proxy := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if err := w.SetTrustedProxies(proxy); err == nil {
+			t.Errorf("SetTrustedProxies(%q) error = nil", proxy)
+		}
+	}
+	if err := w.SetTrustedProxies("::ffff:10.0.0.1"); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	c := &Context{way: w}
+	if !c.trustedProxy("10.0.0.1") {
+		t.Fatal("IPv4-mapped proxy address does not match the IPv4 address")
+	}
+}
+
+func TestAccessLogUsesClientIP(t *testing.T) {
+	var out bytes.Buffer
+	w := New()
+	if err := w.SetTrustedProxies("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	w.Use(AccessLog(AccessLogConfig{Output: &out, Fields: []string{AccessLogFieldRemoteAddr}}))
+	w.GET("/", func(c *Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := out.String(); got != `{"remote_addr":"198.51.100.1"}`+"\n" {
+		t.Fatalf("access log = %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often a memory shard removes expired entries.
+const sweepInterval = time.Minute
+
+// MemoryConfig configures a MemoryStore.
+// Shards is the number of independently locked shards, 64 when zero.
+// MaxKeys is the maximum number of keys kept, 100000 when zero; when a shard is full the
+// entry closest to expiry is evicted.
+type MemoryConfig struct {
+	Shards  int
+	MaxKeys int
+}
+
+// MemoryStore keeps rate limit state in memory. It is safe for concurrent use,
+// but limits are not shared between processes.
+type MemoryStore struct {
+	shards []memoryShard
+}
+
+// memoryShard is a locked part of the store.
+type memoryShard struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	maxKeys   int
+	lastSweep time.Time
+}
+
+// memoryEntry is the state of a key and when it may be discarded.
+type memoryEntry struct {
+	state   State
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore(config MemoryConfig) *MemoryStore {
+	if config.Shards <= 0 {
+		config.Shards = 64
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = 100000
+	}
+	perShard := (config.MaxKeys + config.Shards - 1) / config.Shards
+	s := &MemoryStore{shards: make([]memoryShard, config.Shards)}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*memoryEntry)
+		s.shards[i].maxKeys = perShard
+	}
+	return s
+}
+
+// Update applies fn to the state of key.
+func (s *MemoryStore) Update(ctx context.Context, key string, now, expires time.Time, fn func(*State)) error {
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) >= sweepInterval {
+		shard.sweep(now)
+	}
+	entry, ok := shard.entries[key]
+	if !ok || now.After(entry.expires) {
+		if !ok && len(shard.entries) >= shard.maxKeys {
+			shard.sweep(now)
+			if len(shard.entries) >= shard.maxKeys {
+				shard.evictOne()
+			}
+		}
+		entry = &memoryEntry{}
+		shard.entries[key] = entry
+	}
+	fn(&entry.state)
+	entry.expires = expires
+	return nil
+}
+
+// Len returns the number of keys in the store.
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].entries)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+// shard returns the shard of key.
+func (s *MemoryStore) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// sweep removes expired entries.
+func (m *memoryShard) sweep(now time.Time) {
+	for key, entry := range m.entries {
+		if now.After(entry.expires) {
+			delete(m.entries, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+// evictOne removes the entry closest to expiry.
+func (m *memoryShard) evictOne() {
+	var oldest string
+	var oldestExpires time.Time
+	for key, entry := range m.entries {
+		if oldest == "" || entry.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, entry.expires
+		}
+	}
+	delete(m.entries, oldest)
+}
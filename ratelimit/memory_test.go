@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreConcurrentUpdates(t *testing.T) {
+	s := NewMemoryStore(MemoryConfig{})
+	now := time.Now()
+	expires := now.Add(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update(context.Background(), "key", now, expires, func(st *State) { st.Value++ })
+		}()
+	}
+	wg.Wait()
+
+	var got float64
+	s.Update(context.Background(), "key", now, expires, func(st *State) { got = st.Value })
+	if got != 50 {
+		t.Fatalf("value = %v, want 50", got)
+	}
+}
+
+func TestMemoryStoreExpiresAndEvicts(t *testing.T) {
+	s := NewMemoryStore(MemoryConfig{Shards: 1, MaxKeys: 3})
+	ctx := context.Background()
+	now := time.Now()
+
+	s.Update(ctx, "expired", now, now.Add(time.Second), func(st *State) { st.Value = 7 })
+	s.Update(ctx, "expired", now.Add(2*time.Second), now.Add(time.Minute), func(st *State) {
+		if st.Value != 0 {
+			t.Errorf("expired state value = %v, want 0", st.Value)
+		}
+	})
+
+	for i := 0; i < 5; i++ {
+		s.Update(ctx, strconv.Itoa(i), now, now.Add(time.Duration(i+1)*time.Minute), func(st *State) { st.Value = 1 })
+	}
+	if n := s.Len(); n != 3 {
+		t.Fatalf("Len() = %d, want 3", n)
+	}
+	s.Update(ctx, "4", now, now.Add(time.Hour), func(st *State) {
+		if st.Value != 1 {
+			t.Errorf("the newest key was evicted")
+		}
+	})
+}
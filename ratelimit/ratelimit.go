@@ -0,0 +1,266 @@
+// Package ratelimit provides rate limiting middleware for Way with token bucket and
+// sliding window algorithms and pluggable stores.
+//
+//	w.Use(ratelimit.New(ratelimit.Config{
+//		Limit:  100,
+//		Window: time.Minute,
+//		Key:    ratelimit.ByIP,
+//	}))
+//
+// Limits are kept in a MemoryStore by default. Use a SQLStore to share them between instances.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/swayedev/way"
+)
+
+// Algorithm selects how requests are counted.
+type Algorithm int
+
+const (
+	// TokenBucket allows bursts of up to Burst requests and refills Limit tokens per Window.
+	TokenBucket Algorithm = iota
+	// SlidingWindow allows Limit requests in any Window, weighting the previous fixed window
+	// by how much of it still overlaps the sliding window.
+	SlidingWindow
+)
+
+// State is the rate limit state of one key, stored by a Store.
+// For TokenBucket Value is the number of tokens and Stamp the time of the last refill.
+// For SlidingWindow Value and Prev are the counts of the current and previous windows,
+// and Stamp is the start of the current window.
+type State struct {
+	Value float64
+	Prev  float64
+	Stamp time.Time
+}
+
+// Store keeps rate limit state.
+// Update must apply fn to the state of key atomically, starting from the zero State for
+// unknown keys and for keys whose state expired before now, and may discard the state after
+// expires. now is the time of the request, from Config.Now. fn may be called more than once.
+type Store interface {
+	Update(ctx context.Context, key string, now, expires time.Time, fn func(*State)) error
+}
+
+// KeyFunc returns the key a request is limited by. Requests with an empty key are not limited.
+type KeyFunc func(*way.Context) string
+
+// Config configures the rate limit middleware.
+//
+// Limit is the number of requests allowed per Window. Burst is the TokenBucket capacity,
+// Limit when zero. Name is prefixed to keys so limiters can share a Store.
+// Key defaults to ByIP and Store to a new MemoryStore. Skip reports requests that are not limited.
+// When FailOpen is set, requests are allowed when the store fails; otherwise the store error
+// is passed to the error handler. Now returns the current time, time.Now when nil; it is used
+// by the algorithm and passed to the Store, e.g. to control time in tests.
+type Config struct {
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+	Burst     int
+	Name      string
+	Key       KeyFunc
+	Store     Store
+	Skip      func(*way.Context) bool
+	FailOpen  bool
+	Now       func() time.Time
+}
+
+// Result is the outcome of a rate limit check.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// New returns middleware that limits requests per key.
+//
+// Every response carries the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers. Limited requests also get a Retry-After header and a 429
+// *way.HTTPError with the code "rate_limited" is passed to the error handler.
+// It panics if Limit or Window is not positive.
+func New(config Config) way.MiddlewareFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic("ratelimit: Limit and Window must be positive")
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	if config.Key == nil {
+		config.Key = ByIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore(MemoryConfig{})
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	policy := strconv.Itoa(config.Limit) + ";w=" + strconv.Itoa(int(math.Ceil(config.Window.Seconds())))
+	if config.Algorithm == TokenBucket {
+		policy = strconv.Itoa(config.Burst) + ";w=" + strconv.Itoa(int(math.Ceil(config.Window.Seconds()*float64(config.Burst)/float64(config.Limit))))
+	}
+
+	return func(next way.HandlerFunc) way.HandlerFunc {
+		return func(c *way.Context) {
+			if config.Skip != nil && config.Skip(c) {
+				next(c)
+				return
+			}
+			key := config.Key(c)
+			if key == "" {
+				next(c)
+				return
+			}
+			result, err := config.take(c.Request.Context(), config.Name+key)
+			if err != nil {
+				if config.FailOpen {
+					c.Slog().Warn("rate limit store failed", "error", err)
+					next(c)
+					return
+				}
+				c.Error(way.NewHTTPError(http.StatusInternalServerError, "").Wrap(err))
+				return
+			}
+
+			header := c.Response.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", seconds(result.Reset))
+			header.Set("RateLimit-Policy", policy)
+			if !result.Allowed {
+				header.Set("Retry-After", seconds(result.RetryAfter))
+				c.Error(way.NewHTTPError(http.StatusTooManyRequests, "").WithCode("rate_limited"))
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// take counts a request for key in the store.
+func (config *Config) take(ctx context.Context, key string) (Result, error) {
+	now := config.Now()
+	var result Result
+	var expires time.Time
+	var fn func(*State)
+	switch config.Algorithm {
+	case SlidingWindow:
+		expires = now.Add(2 * config.Window)
+		fn = func(s *State) { result = slidingWindow(s, now, config.Limit, config.Window) }
+	default:
+		rate := float64(config.Limit) / config.Window.Seconds()
+		expires = now.Add(time.Duration(float64(config.Burst) / rate * float64(time.Second)))
+		fn = func(s *State) { result = tokenBucket(s, now, config.Burst, rate) }
+	}
+	err := config.Store.Update(ctx, key, now, expires, fn)
+	return result, err
+}
+
+// tokenBucket refills the bucket for the time since the last request and takes a token.
+// rate is the number of tokens added per second.
+func tokenBucket(s *State, now time.Time, burst int, rate float64) Result {
+	capacity := float64(burst)
+	if s.Stamp.IsZero() {
+		s.Value = capacity
+	} else if elapsed := now.Sub(s.Stamp).Seconds(); elapsed > 0 {
+		s.Value = math.Min(capacity, s.Value+elapsed*rate)
+	}
+	s.Stamp = now
+
+	result := Result{Limit: burst}
+	if s.Value >= 1 {
+		s.Value--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = toDuration((1 - s.Value) / rate)
+	}
+	result.Remaining = int(s.Value)
+	result.Reset = toDuration((capacity - s.Value) / rate)
+	return result
+}
+
+// slidingWindow estimates the number of requests in the window ending now from the
+// current and previous fixed windows and counts the request if it is below limit.
+func slidingWindow(s *State, now time.Time, limit int, window time.Duration) Result {
+	start := now.Truncate(window)
+	switch {
+	case s.Stamp.Equal(start):
+	case s.Stamp.Add(window).Equal(start):
+		s.Prev, s.Value = s.Value, 0
+	default:
+		s.Prev, s.Value = 0, 0
+	}
+	s.Stamp = start
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(window)
+	estimate := s.Prev*weight + s.Value
+
+	result := Result{Limit: limit, Reset: window - elapsed}
+	if estimate+1 <= float64(limit) {
+		s.Value++
+		estimate++
+		result.Allowed = true
+	} else if s.Prev > 0 && s.Value+1 <= float64(limit) {
+		// The request fits once enough of the previous window has slid out.
+		wait := (1-(float64(limit)-1-s.Value)/s.Prev)*float64(window) - float64(elapsed)
+		result.RetryAfter = time.Duration(math.Max(wait, 0))
+	} else {
+		result.RetryAfter = window - elapsed
+	}
+	result.Remaining = int(math.Max(0, float64(limit)-math.Ceil(estimate)))
+	return result
+}
+
+// ByIP limits requests by the client IP address from Context.ClientIP,
+// so configure Way.SetTrustedProxies when running behind a load balancer.
+func ByIP(c *way.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser limits requests by the value stored under key with Context.Set,
+// such as the user ID set by authentication middleware. Anonymous requests are not limited.
+func ByUser(key string) KeyFunc {
+	return func(c *way.Context) string {
+		value, ok := c.Get(key)
+		if !ok || value == nil {
+			return ""
+		}
+		return "user:" + fmt.Sprint(value)
+	}
+}
+
+// ByAPIKey limits requests by the API key sent in header. The key is hashed so that
+// it is not kept in the store. Requests without the header are not limited.
+func ByAPIKey(header string) KeyFunc {
+	return func(c *way.Context) string {
+		key := c.Request.Header.Get(header)
+		if key == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "apikey:" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// toDuration converts seconds to a Duration.
+func toDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// seconds formats d as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
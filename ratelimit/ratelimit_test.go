@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/swayedev/way"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func limitedServer(config Config) *way.Way {
+	w := way.New()
+	w.Use(New(config))
+	w.GET("/", func(c *way.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return w
+}
+
+// requestFrom returns a GET request for / from remoteAddr.
+func requestFrom(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+// serve serves req with h and returns the recorded response.
+func serve(t *testing.T, h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTokenBucketMiddleware(t *testing.T) {
+	clk := &clock{now: time.Now()}
+	w := limitedServer(Config{Limit: 2, Window: 2 * time.Second, Now: clk.Now})
+
+	for i, want := range []string{"1", "0"} {
+		rec := serve(t, w, requestFrom("192.0.2.1:1234"))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, http.StatusNoContent)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != want {
+			t.Fatalf("request %d RateLimit-Remaining = %q, want %q", i, got, want)
+		}
+	}
+
+	rec := serve(t, w, requestFrom("192.0.2.1:1234"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	headers := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "2",
+		"RateLimit-Policy":    "2;w=2",
+		"Retry-After":         "1",
+	}
+	for name, want := range headers {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if rec := serve(t, w, requestFrom("192.0.2.2:1234")); rec.Code != http.StatusNoContent {
+		t.Fatalf("other client status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	clk.now = clk.now.Add(time.Second)
+	if rec := serve(t, w, requestFrom("192.0.2.1:1234")); rec.Code != http.StatusNoContent {
+		t.Fatalf("status after refill = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	now := time.Now()
+	var s State
+	for i := 0; i < 5; i++ {
+		if r := tokenBucket(&s, now, 5, 1); !r.Allowed {
+			t.Fatalf("request %d was limited within the burst", i)
+		}
+	}
+	r := tokenBucket(&s, now, 5, 1)
+	if r.Allowed || r.RetryAfter != time.Second || r.Reset != 5*time.Second {
+		t.Fatalf("result = %+v, want limited with a 1s retry", r)
+	}
+	if r := tokenBucket(&s, now.Add(time.Hour), 5, 1); !r.Allowed || r.Remaining != 4 {
+		t.Fatalf("result after an hour = %+v, want a full bucket", r)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	start := time.Now().Truncate(time.Minute)
+	var s State
+	for i := 0; i < 10; i++ {
+		if r := slidingWindow(&s, start.Add(time.Second), 10, time.Minute); !r.Allowed {
+			t.Fatalf("request %d was limited", i)
+		}
+	}
+	if r := slidingWindow(&s, start.Add(59*time.Second), 10, time.Minute); r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("result = %+v, want limited until the window ends", r)
+	}
+
+	// A quarter into the next window, 75% of the previous 10 requests still count.
+	r := slidingWindow(&s, start.Add(75*time.Second), 10, time.Minute)
+	if !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("result = %+v, want allowed with 1 remaining", r)
+	}
+	r = slidingWindow(&s, start.Add(75*time.Second), 10, time.Minute)
+	if !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("result = %+v, want allowed with 0 remaining", r)
+	}
+	r = slidingWindow(&s, start.Add(75*time.Second), 10, time.Minute)
+	if r.Allowed || r.RetryAfter != 3*time.Second {
+		t.Fatalf("result = %+v, want limited for 3s", r)
+	}
+
+	if r := slidingWindow(&s, start.Add(5*time.Minute), 10, time.Minute); !r.Allowed || r.Remaining != 9 {
+		t.Fatalf("result after idle windows = %+v, want a fresh window", r)
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	w := way.New()
+	var keys []string
+	w.Use(func(next way.HandlerFunc) way.HandlerFunc {
+		return func(c *way.Context) {
+			c.Set("user", 42)
+			keys = append(keys, ByIP(c), ByUser("user")(c), ByUser("missing")(c), ByAPIKey("X-API-Key")(c), ByAPIKey("X-Other")(c))
+			next(c)
+		}
+	})
+	w.GET("/", func(c *way.Context) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	req.Header.Set("X-API-Key", "secret")
+	serve(t, w, req)
+
+	want := []string{"ip:2001:db8::1", "user:42", "", "apikey:2bb80d537b1da3e38bd30361aa855686", ""}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d = %q, want %q", i, keys[i], want[i])
+		}
+	}
+}
+
+// failingStore is a Store that always fails.
+type failingStore struct{}
+
+func (failingStore) Update(context.Context, string, time.Time, time.Time, func(*State)) error {
+	return errors.New("store down")
+}
+
+func TestStoreFailures(t *testing.T) {
+	if rec := serve(t, limitedServer(Config{Limit: 1, Window: time.Second, Store: failingStore{}}), requestFrom("192.0.2.1:1")); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if rec := serve(t, limitedServer(Config{Limit: 1, Window: time.Second, Store: failingStore{}, FailOpen: true}), requestFrom("192.0.2.1:1")); rec.Code != http.StatusNoContent {
+		t.Fatalf("FailOpen status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestSkipAndEmptyKeys(t *testing.T) {
+	w := limitedServer(Config{
+		Limit:  1,
+		Window: time.Hour,
+		Key:    ByAPIKey("X-API-Key"),
+		Skip:   func(c *way.Context) bool { return c.Request.Header.Get("X-Internal") != "" },
+	})
+	for i := 0; i < 3; i++ {
+		if rec := serve(t, w, requestFrom("192.0.2.1:1")); rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request without key = %d %v, want unlimited", rec.Code, rec.Header())
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", "secret")
+		req.Header.Set("X-Internal", "1")
+		if rec := serve(t, w, req); rec.Code != http.StatusNoContent {
+			t.Fatalf("skipped request status = %d, want %d", rec.Code, http.StatusNoContent)
+		}
+	}
+}
+
+func TestNewPanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic without a limit")
+		}
+	}()
+	New(Config{Window: time.Second})
+}
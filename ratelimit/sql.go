@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swayedev/way"
+)
+
+// ErrConflict is returned by SQLStore.Update when the state of a key kept changing
+// concurrently and could not be updated.
+var ErrConflict = errors.New("ratelimit: concurrent update conflict")
+
+// sqlRetries is the number of times SQLStore.Update retries after a concurrent update.
+const sqlRetries = 5
+
+// validTable matches the table names accepted by NewSQLStore.
+var validTable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLStore keeps rate limit state in a database table through way.DB, so that several
+// instances of an application share their limits. Rows are updated with optimistic
+// locking on a version column, which works the same on every supported database:
+// SQLite, PostgreSQL, CockroachDB, MySQL, SQL Server and Oracle.
+//
+// The table has this schema, created by CreateTable; Oracle uses NUMBER(19) for the BIGINT
+// columns:
+//
+//	CREATE TABLE way_rate_limits (
+//		limit_key VARCHAR(255) PRIMARY KEY,
+//		value DOUBLE PRECISION NOT NULL,
+//		prev DOUBLE PRECISION NOT NULL,
+//		stamp BIGINT NOT NULL,
+//		expires BIGINT NOT NULL,
+//		version BIGINT NOT NULL
+//	)
+type SQLStore struct {
+	db    *way.DB
+	table string
+}
+
+// NewSQLStore returns a store using table in db, way_rate_limits when table is empty.
+// It returns an error if table is not a valid identifier or the driver of db is not supported.
+func NewSQLStore(db *way.DB, table string) (*SQLStore, error) {
+	if table == "" {
+		table = "way_rate_limits"
+	}
+	if !validTable.MatchString(table) {
+		return nil, fmt.Errorf("ratelimit: invalid table name %q", table)
+	}
+	switch db.Driver {
+	case "sqlite3", "pgx", "mysql", "sqlserver", "godror":
+	default:
+		return nil, fmt.Errorf("ratelimit: unsupported database driver %q", db.Driver)
+	}
+	return &SQLStore{db: db, table: table}, nil
+}
+
+// CreateTable creates the table if it does not exist.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	return s.db.ExecNoResult(ctx, s.createTable())
+}
+
+// createTable returns the statement creating the table if it does not exist for the driver.
+func (s *SQLStore) createTable() string {
+	bigint := "BIGINT"
+	if s.db.Driver == "godror" {
+		bigint = "NUMBER(19)"
+	}
+	create := "CREATE TABLE " + s.table + ` (
+	limit_key VARCHAR(255) PRIMARY KEY,
+	value DOUBLE PRECISION NOT NULL,
+	prev DOUBLE PRECISION NOT NULL,
+	stamp ` + bigint + ` NOT NULL,
+	expires ` + bigint + ` NOT NULL,
+	version ` + bigint + ` NOT NULL
+)`
+	switch s.db.Driver {
+	case "sqlserver":
+		create = "IF OBJECT_ID(N'" + s.table + "', N'U') IS NULL " + create
+	case "godror":
+		// Oracle has no IF NOT EXISTS before 23ai; ORA-00955 means the table exists.
+		create = "BEGIN EXECUTE IMMEDIATE '" + create + "'; EXCEPTION WHEN OTHERS THEN IF SQLCODE != -955 THEN RAISE; END IF; END;"
+	default:
+		create = strings.Replace(create, "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1)
+	}
+	return create
+}
+
+// DeleteExpired removes the state of keys that have expired. Call it periodically
+// to keep the table small.
+func (s *SQLStore) DeleteExpired(ctx context.Context) error {
+	return s.db.ExecNoResult(ctx, s.query("DELETE FROM "+s.table+" WHERE expires < ?"), time.Now().UnixNano())
+}
+
+// Update applies fn to the state of key, retrying when another instance updated it concurrently.
+func (s *SQLStore) Update(ctx context.Context, key string, now, expires time.Time, fn func(*State)) error {
+	selectQuery := s.query("SELECT value, prev, stamp, expires, version FROM " + s.table + " WHERE limit_key = ?")
+	insertQuery := s.query("INSERT INTO " + s.table + " (limit_key, value, prev, stamp, expires, version) VALUES (?, ?, ?, ?, ?, 1)")
+	updateQuery := s.query("UPDATE " + s.table + " SET value = ?, prev = ?, stamp = ?, expires = ?, version = version + 1 WHERE limit_key = ? AND version = ?")
+
+	var insertErr error
+	for attempt := 0; attempt < sqlRetries; attempt++ {
+		var state State
+		var stamp, rowExpires, version int64
+		err := s.scan(ctx, selectQuery, []interface{}{key}, &state.Value, &state.Prev, &stamp, &rowExpires, &version)
+		found := err == nil
+		if err != nil && !errors.Is(err, way.SqlErrNoRows) && !errors.Is(err, way.PgxErrNoRows) {
+			return err
+		}
+		if found && stamp != 0 && now.UnixNano() <= rowExpires {
+			state.Stamp = time.Unix(0, stamp)
+		} else {
+			state = State{}
+		}
+
+		fn(&state)
+		var newStamp int64
+		if !state.Stamp.IsZero() {
+			newStamp = state.Stamp.UnixNano()
+		}
+
+		if !found {
+			// A failed insert is retried in case another instance inserted the key concurrently,
+			// but if the key still does not exist the insert failed for another reason.
+			if insertErr != nil {
+				return insertErr
+			}
+			if insertErr = s.db.ExecNoResult(ctx, insertQuery, key, state.Value, state.Prev, newStamp, expires.UnixNano()); insertErr == nil {
+				return nil
+			}
+			continue
+		}
+		affected, err := s.exec(ctx, updateQuery, state.Value, state.Prev, newStamp, expires.UnixNano(), key, version)
+		if err != nil {
+			return err
+		}
+		if affected == 1 {
+			return nil
+		}
+	}
+	return ErrConflict
+}
+
+// scan runs a single-row query and scans its columns into dest.
+func (s *SQLStore) scan(ctx context.Context, query string, args []interface{}, dest ...interface{}) error {
+	if s.db.UsePgx {
+		row := s.db.PGXQueryRow(ctx, query, args...)
+		if row == nil {
+			return errors.New("pgx database connection is not initialized")
+		}
+		return row.Scan(dest...)
+	}
+	row := s.db.SQLQueryRow(ctx, query, args...)
+	if row == nil {
+		return errors.New("sql database connection is not initialized")
+	}
+	return row.Scan(dest...)
+}
+
+// exec runs a statement and returns the number of affected rows.
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if s.db.UsePgx {
+		tag, err := s.db.PGXExec(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return tag.RowsAffected(), nil
+	}
+	result, err := s.db.SQLExec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// query rewrites the ? placeholders of query for the database driver.
+func (s *SQLStore) query(query string) string {
+	var prefix string
+	switch s.db.Driver {
+	case "pgx", "postgres", "cockroachdb":
+		prefix = "$"
+	case "sqlserver":
+		prefix = "@p"
+	case "godror":
+		prefix = ":"
+	default:
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString(prefix)
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
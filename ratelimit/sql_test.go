@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swayedev/way"
+	_ "github.com/swayedev/way/database/drivers/sqlite"
+)
+
+func newSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db := way.NewDB()
+	db.SQLNew(conn, "sqlite3")
+	store, err := NewSQLStore(&db, "")
+	if err != nil {
+		t.Fatalf("NewSQLStore() error = %v", err)
+	}
+	if err := store.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+	return store
+}
+
+func TestSQLStoreSharesLimits(t *testing.T) {
+	store := newSQLStore(t)
+	config := Config{Limit: 2, Window: time.Minute, Algorithm: SlidingWindow, Store: store}
+	first, second := limitedServer(config), limitedServer(config)
+
+	for i, w := range []*way.Way{first, second, first} {
+		want := http.StatusNoContent
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if rec := serve(t, w, requestFrom("192.0.2.1:1")); rec.Code != want {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestSQLStoreUpdate(t *testing.T) {
+	store := newSQLStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	stamp := now.Truncate(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				err := store.Update(ctx, "key", now, now.Add(time.Minute), func(s *State) {
+					s.Value++
+					s.Stamp = stamp
+				})
+				if err != ErrConflict {
+					if err != nil {
+						t.Errorf("Update() error = %v", err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got State
+	if err := store.Update(ctx, "key", now, now.Add(time.Minute), func(s *State) { got = *s }); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got.Value != 10 || !got.Stamp.Equal(stamp) {
+		t.Fatalf("state = %+v, want 10 updates at %v", got, stamp)
+	}
+
+	if err := store.Update(ctx, "old", now, now.Add(-time.Second), func(s *State) { s.Value = 3; s.Stamp = stamp }); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := store.DeleteExpired(ctx); err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	var rows int
+	if err := store.db.SQL().QueryRow("SELECT COUNT(*) FROM way_rate_limits").Scan(&rows); err != nil || rows != 1 {
+		t.Fatalf("rows = %d, %v, want 1", rows, err)
+	}
+}
+
+func TestSQLStorePlaceholders(t *testing.T) {
+	tests := map[string]string{
+		"sqlite3":   "a = ? AND b = ?",
+		"pgx":       "a = $1 AND b = $2",
+		"sqlserver": "a = @p1 AND b = @p2",
+		"godror":    "a = :1 AND b = :2",
+	}
+	for driver, want := range tests {
+		store := &SQLStore{db: &way.DB{Driver: driver}}
+		if got := store.query("a = ? AND b = ?"); got != want {
+			t.Errorf("%s query = %q, want %q", driver, got, want)
+		}
+	}
+	if _, err := NewSQLStore(&way.DB{Driver: "sqlite3"}, "limits; DROP TABLE users"); err == nil {
+		t.Fatal("NewSQLStore() accepted an invalid table name")
+	}
+}
+
+func TestSQLStoreCreateTable(t *testing.T) {
+	tests := map[string][]string{
+		"sqlite3":   {"CREATE TABLE IF NOT EXISTS limits (", "stamp BIGINT"},
+		"sqlserver": {"IF OBJECT_ID(N'limits', N'U') IS NULL CREATE TABLE limits (", "stamp BIGINT"},
+		"godror":    {"BEGIN EXECUTE IMMEDIATE 'CREATE TABLE limits (", "stamp NUMBER(19)", "SQLCODE != -955"},
+	}
+	for driver, parts := range tests {
+		store, err := NewSQLStore(&way.DB{Driver: driver}, "limits")
+		if err != nil {
+			t.Fatalf("%s NewSQLStore() error = %v", driver, err)
+		}
+		query := store.createTable()
+		for _, part := range parts {
+			if !strings.Contains(query, part) {
+				t.Errorf("%s CREATE TABLE = %q, want it to contain %q", driver, query, part)
+			}
+		}
+	}
+	if _, err := NewSQLStore(&way.DB{Driver: "clickhouse"}, ""); err == nil {
+		t.Fatal("NewSQLStore() accepted an unsupported driver")
+	}
+}
@@ -0,0 +1,90 @@
+package way
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// PanicError is the error passed to the error handler when Recover catches a panic.
+// Value is the value passed to panic and Stack the captured stack trace, if any.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecoverConfig configures the Recover middleware.
+// StackSize is the maximum number of stack trace bytes captured, 4 KB when zero.
+// DisableStack disables stack capture.
+// RepanicAbortHandler re-panics http.ErrAbortHandler so that net/http aborts the
+// response without logging, instead of rendering a 500 response.
+type RecoverConfig struct {
+	StackSize           int
+	DisableStack        bool
+	RepanicAbortHandler bool
+}
+
+// DefaultRecoverConfig is the configuration used by Recover.
+var DefaultRecoverConfig = RecoverConfig{
+	StackSize:           4 << 10,
+	RepanicAbortHandler: true,
+}
+
+// Recover returns middleware that recovers from panics in later middleware and handlers
+// using DefaultRecoverConfig. See RecoverWithConfig.
+func Recover() MiddlewareFunc {
+	return RecoverWithConfig(DefaultRecoverConfig)
+}
+
+// RecoverWithConfig returns middleware that recovers from panics in later middleware and handlers.
+// A *PanicError holding the panic value and stack trace is passed to the error handler, so a 500
+// response is sent and DefaultErrorHandler logs the panic once with its stack. When the response
+// has already been written, the panic is logged through the request logger instead.
+// Add it with Use before other middleware so that it can catch their panics too.
+func RecoverWithConfig(config RecoverConfig) MiddlewareFunc {
+	if config.StackSize <= 0 {
+		config.StackSize = DefaultRecoverConfig.StackSize
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			defer func() {
+				value := recover()
+				if value == nil {
+					return
+				}
+				if config.RepanicAbortHandler && isAbortHandler(value) {
+					panic(value)
+				}
+				panicErr := &PanicError{Value: value}
+				if !config.DisableStack {
+					stack := make([]byte, config.StackSize)
+					panicErr.Stack = stack[:runtime.Stack(stack, false)]
+				}
+				if c.Written() {
+					c.Slog().Error("recovered from panic after the response was written", "panic", fmt.Sprint(value), "stack", string(panicErr.Stack))
+					return
+				}
+				c.Error(NewHTTPError(http.StatusInternalServerError, "").Wrap(panicErr))
+			}()
+			next(c)
+		}
+	}
+}
+
+// isAbortHandler reports whether the panic value is http.ErrAbortHandler.
+func isAbortHandler(value interface{}) bool {
+	err, ok := value.(error)
+	return ok && errors.Is(err, http.ErrAbortHandler)
+}
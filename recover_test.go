@@ -0,0 +1,104 @@
+package way
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverRendersInternalServerError(t *testing.T) {
+	var logs bytes.Buffer
+	w := New()
+	w.SetLogger(log.New(&logs, "", 0))
+	w.Use(RequestID(RequestIDConfig{}), Recover())
+	w.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if strings.Contains(rec.Body.String(), "boom") {
+		t.Fatalf("response exposes panic value: %s", rec.Body.String())
+	}
+	for _, want := range []string{"boom", "req-123", "method=GET", "path=/panic", "goroutine"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("log %q does not contain %q", logs.String(), want)
+		}
+	}
+	if n := strings.Count(logs.String(), "level=ERROR"); n != 1 {
+		t.Fatalf("logged %d errors, want 1: %q", n, logs.String())
+	}
+}
+
+func TestRecoverPassesPanicErrorToErrorHandler(t *testing.T) {
+	w := New()
+	w.SetLogger(log.New(&bytes.Buffer{}, "", 0))
+	var got *PanicError
+	w.SetErrorHandler(func(c *Context, err error) {
+		errors.As(err, &got)
+		c.Status(http.StatusTeapot)
+	})
+	w.Use(RecoverWithConfig(RecoverConfig{DisableStack: true}))
+	w.GET("/panic", func(c *Context) {
+		panic(errors.New("broken"))
+	})
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if got == nil || got.Stack != nil || got.Unwrap() == nil || got.Unwrap().Error() != "broken" {
+		t.Fatalf("panic error = %#v", got)
+	}
+}
+
+func TestRecoverDoesNotOverwriteWrittenResponse(t *testing.T) {
+	w := New()
+	w.SetLogger(log.New(&bytes.Buffer{}, "", 0))
+	w.Use(Recover())
+	w.GET("/partial", func(c *Context) {
+		c.String(http.StatusAccepted, "partial")
+		panic("after write")
+	})
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/partial", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Fatalf("response = %d %q, want 202 partial", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRecoverRepanicsAbortHandler(t *testing.T) {
+	w := New()
+	w.SetLogger(log.New(&bytes.Buffer{}, "", 0))
+	w.Use(Recover())
+	w.GET("/abort", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if value := recover(); value != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", value)
+		}
+	}()
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	t.Fatal("expected panic")
+}
@@ -0,0 +1,116 @@
+package way
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultRequestIDHeader is the header read and written by the RequestID middleware.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request ID accepted by the RequestID middleware.
+const maxRequestIDLength = 128
+
+// RequestIDConfig configures the RequestID middleware.
+// Header is the request and response header, DefaultRequestIDHeader when empty.
+// Generator creates IDs for requests without a valid incoming ID, NewUUIDv7 when nil.
+// IgnoreIncoming always generates a new ID instead of trusting the request header.
+type RequestIDConfig struct {
+	Header         string
+	Generator      func() string
+	IgnoreIncoming bool
+}
+
+// RequestID returns middleware that reads the request ID from the request header, or generates
+// one when it is missing or invalid, stores it on the Context and sets it on the response.
+// Incoming IDs are accepted when they are at most 128 characters of letters, digits, '-', '_', '.' and ':'.
+//
+// The ID is then available from Context.RequestID, added to the records of Context.Slog,
+// Context.Log and the database helpers, included in problem responses and forwarded by ProxyMedia.
+// Register it with Use before other middleware so that their logs include the ID.
+func RequestID(config RequestIDConfig) MiddlewareFunc {
+	if config.Header == "" {
+		config.Header = DefaultRequestIDHeader
+	}
+	if config.Generator == nil {
+		config.Generator = NewUUIDv7
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			id := c.Request.Header.Get(config.Header)
+			if config.IgnoreIncoming || !validRequestID(id) {
+				id = config.Generator()
+			}
+			c.requestID = id
+			c.requestIDHeader = config.Header
+			c.Response.Header().Set(config.Header, id)
+			next(c)
+		}
+	}
+}
+
+// RequestID returns the request ID set by the RequestID middleware, or an empty string.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// validRequestID reports whether an incoming request ID is safe to use and log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		switch {
+		case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv7 returns a new time-ordered RFC 9562 version 7 UUID in canonical form.
+func NewUUIDv7() string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(id[6:])
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+
+	var out [36]byte
+	hex.Encode(out[0:8], id[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], id[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], id[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], id[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], id[10:])
+	return string(out[:])
+}
+
+// crockfordBase32 is the alphabet used by ULIDs.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a new 26 character ULID: a 48-bit millisecond timestamp followed by 80 random bits.
+func NewULID() string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(id[6:])
+
+	// Encode the 128 bits as 26 base32 characters, the first holding the top 3 bits.
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
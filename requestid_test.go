@@ -0,0 +1,119 @@
+package way
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDGeneratesAndEchoesID(t *testing.T) {
+	w := New()
+	w.Use(RequestID(RequestIDConfig{}))
+	var got string
+	w.GET("/ping", func(c *Context) {
+		got = c.RequestID()
+	})
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	if !uuidPattern.MatchString(got) || got[14] != '7' {
+		t.Fatalf("RequestID() = %q, want a UUIDv7", got)
+	}
+	if rec.Header().Get(DefaultRequestIDHeader) != got {
+		t.Fatalf("response header = %q, want %q", rec.Header().Get(DefaultRequestIDHeader), got)
+	}
+}
+
+func TestRequestIDKeepsValidIncomingID(t *testing.T) {
+	w := New()
+	w.Use(RequestID(RequestIDConfig{Header: "X-Correlation-ID", Generator: NewULID}))
+	var got string
+	w.GET("/ping", func(c *Context) {
+		got = c.RequestID()
+	})
+
+	for incoming, keep := range map[string]bool{
+		"abc-123":                   true,
+		"bad id\nwith newline":      false,
+		strings.Repeat("a", 129):    false,
+		"trace:span.1_two":          true,
+		"":                          false,
+		"<script>alert(1)</script>": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("X-Correlation-ID", incoming)
+		w.router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if keep && got != incoming {
+			t.Fatalf("RequestID() = %q, want incoming %q", got, incoming)
+		}
+		if !keep && (got == incoming || len(got) != 26) {
+			t.Fatalf("RequestID() = %q, want a generated ULID for %q", got, incoming)
+		}
+	}
+}
+
+func TestRequestIDInProblemAndLogs(t *testing.T) {
+	var logs bytes.Buffer
+	w := New()
+	w.SetLogger(log.New(&logs, "", 0))
+	w.Use(RequestID(RequestIDConfig{}))
+	w.GET("/fail", E(func(c *Context) error {
+		c.Log().Printf("legacy log line")
+		return NewHTTPError(http.StatusConflict, "conflict")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Extensions["request_id"] != "req-42" {
+		t.Fatalf("request_id = %v, want req-42", body.Extensions["request_id"])
+	}
+	if !strings.Contains(logs.String(), `msg="legacy log line" method=GET path=/fail request_id=req-42`) {
+		t.Fatalf("logs = %q, want request_id attribute", logs.String())
+	}
+}
+
+func TestProxyMediaForwardsRequestID(t *testing.T) {
+	var forwarded string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Trace-ID")
+		w.Write([]byte("media"))
+	}))
+	defer upstream.Close()
+
+	w := New()
+	w.Use(RequestID(RequestIDConfig{Header: "X-Trace-ID"}))
+	w.GET("/media", func(c *Context) {
+		c.ProxyMedia(upstream.URL)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/media", nil)
+	req.Header.Set("X-Trace-ID", "trace-7")
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if forwarded != "trace-7" || rec.Body.String() != "media" {
+		t.Fatalf("forwarded = %q, body = %q", forwarded, rec.Body.String())
+	}
+}
+
+func TestNewULIDFormat(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	a, b := NewULID(), NewULID()
+	if !pattern.MatchString(a) || a == b {
+		t.Fatalf("NewULID() = %q, %q", a, b)
+	}
+}
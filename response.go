@@ -0,0 +1,120 @@
+package way
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter wraps an http.ResponseWriter and records the status code and number of body bytes written.
+// Way installs it on every Context it creates. It supports http.Flusher, http.Hijacker and io.ReaderFrom
+// when the underlying writer does.
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	bytes   int64
+	written bool
+}
+
+// newResponseWriter wraps w.
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code and writes the header.
+// Informational 1xx responses other than 101 do not count as writing the response.
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.written && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		w.status = code
+		w.written = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data, sending a 200 status first if no status was written.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// ReadFrom copies from r, using the underlying writer's io.ReaderFrom when available
+// so that net/http can use sendfile.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, r)
+	}
+	w.bytes += n
+	return n, err
+}
+
+// Hijack lets the caller take over the connection.
+// The response counts as written afterwards, since the handler may no longer use it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && !w.written {
+		w.status = http.StatusSwitchingProtocols
+		w.written = true
+	}
+	return conn, rw, err
+}
+
+// Flush sends any buffered data to the client.
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError sends any buffered data to the client and reports whether the
+// underlying writer supports flushing. It is used by http.ResponseController.
+func (w *responseWriter) FlushError() error {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerOnly hides any optional interfaces of an io.Writer, so io.Copy does not call ReadFrom recursively.
+type writerOnly struct {
+	io.Writer
+}
+
+// Written reports whether the response status has been written.
+// Once it has, headers can no longer be changed and error responses can no longer be sent.
+// It always returns false for contexts created with NewContext.
+func (c *Context) Written() bool {
+	return c.writer != nil && c.writer.written
+}
+
+// StatusCode returns the response status code, or 0 if it has not been written yet.
+// It always returns 0 for contexts created with NewContext.
+func (c *Context) StatusCode() int {
+	if c.writer == nil {
+		return 0
+	}
+	return c.writer.status
+}
+
+// BytesWritten returns the number of response body bytes written so far.
+// It always returns 0 for contexts created with NewContext.
+func (c *Context) BytesWritten() int64 {
+	if c.writer == nil {
+		return 0
+	}
+	return c.writer.bytes
+}
@@ -0,0 +1,239 @@
+package way
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// Content-Security-Policy source keywords.
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+)
+
+// CSP builds a Content-Security-Policy. Directives are written in the order they are first added.
+//
+//	policy := way.NewCSP().
+//		Add("default-src", way.CSPSelf).
+//		Add("img-src", way.CSPSelf, "https://cdn.example.com").
+//		Nonce("script-src", "style-src")
+type CSP struct {
+	directives []cspDirective
+}
+
+// cspDirective is a directive with its sources. nonce adds the request nonce as a source.
+type cspDirective struct {
+	name    string
+	sources []string
+	nonce   bool
+}
+
+// NewCSP returns an empty policy.
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Add appends sources to the directive, adding the directive if needed.
+// Directives without sources, such as upgrade-insecure-requests, are added with no arguments.
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	d := p.directive(directive)
+	d.sources = append(d.sources, sources...)
+	return p
+}
+
+// Nonce adds the per-request nonce as a source of each directive, adding 'self' to directives
+// that do not exist yet. The nonce is available from Context.CSPNonce.
+func (p *CSP) Nonce(directives ...string) *CSP {
+	for _, directive := range directives {
+		d := p.directive(directive)
+		if len(d.sources) == 0 {
+			d.sources = append(d.sources, CSPSelf)
+		}
+		d.nonce = true
+	}
+	return p
+}
+
+// Has reports whether the policy contains the directive.
+func (p *CSP) Has(directive string) bool {
+	for _, d := range p.directives {
+		if d.name == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the policy for the given nonce, which may be empty when no directive uses one.
+func (p *CSP) String(nonce string) string {
+	parts := make([]string, 0, len(p.directives))
+	for _, d := range p.directives {
+		part := append([]string{d.name}, d.sources...)
+		if d.nonce && nonce != "" {
+			part = append(part, "'nonce-"+nonce+"'")
+		}
+		parts = append(parts, strings.Join(part, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// usesNonce reports whether any directive uses the request nonce.
+func (p *CSP) usesNonce() bool {
+	for _, d := range p.directives {
+		if d.nonce {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the policy, so the middleware is not affected by later changes.
+func (p *CSP) clone() *CSP {
+	c := &CSP{directives: make([]cspDirective, len(p.directives))}
+	for i, d := range p.directives {
+		d.sources = append([]string(nil), d.sources...)
+		c.directives[i] = d
+	}
+	return c
+}
+
+// directive returns the named directive, adding it if needed.
+func (p *CSP) directive(name string) *cspDirective {
+	for i := range p.directives {
+		if p.directives[i].name == name {
+			return &p.directives[i]
+		}
+	}
+	p.directives = append(p.directives, cspDirective{name: name})
+	return &p.directives[len(p.directives)-1]
+}
+
+// SecureHeadersConfig configures the SecureHeaders middleware. Empty fields omit their header.
+//
+// HSTSMaxAge is the Strict-Transport-Security max-age in seconds. FrameOptions is the
+// X-Frame-Options value, DENY or SAMEORIGIN, and is also added to ContentSecurityPolicy as
+// frame-ancestors when the policy does not set it. CSPReportOnly sends the policy as
+// Content-Security-Policy-Report-Only so violations are reported without being blocked.
+type SecureHeadersConfig struct {
+	HSTSMaxAge                int
+	HSTSIncludeSubdomains     bool
+	HSTSPreload               bool
+	ContentTypeNosniff        bool
+	FrameOptions              string
+	ReferrerPolicy            string
+	PermissionsPolicy         string
+	CrossOriginOpenerPolicy   string
+	CrossOriginEmbedderPolicy string
+	CrossOriginResourcePolicy string
+	ContentSecurityPolicy     *CSP
+	CSPReportOnly             bool
+}
+
+// DefaultSecureHeadersConfig is the configuration used by SecureHeaders.
+// Cross-Origin-Embedder-Policy is not set since require-corp blocks most third-party content.
+var DefaultSecureHeadersConfig = SecureHeadersConfig{
+	HSTSMaxAge:                63072000,
+	HSTSIncludeSubdomains:     true,
+	ContentTypeNosniff:        true,
+	FrameOptions:              "DENY",
+	ReferrerPolicy:            "strict-origin-when-cross-origin",
+	PermissionsPolicy:         "camera=(), microphone=(), geolocation=()",
+	CrossOriginOpenerPolicy:   "same-origin",
+	CrossOriginResourcePolicy: "same-origin",
+	ContentSecurityPolicy: NewCSP().
+		Add("default-src", CSPSelf).
+		Add("base-uri", CSPSelf).
+		Add("object-src", CSPNone),
+}
+
+// SecureHeaders returns middleware that sets security headers using DefaultSecureHeadersConfig.
+// See SecureHeadersWithConfig.
+func SecureHeaders() MiddlewareFunc {
+	return SecureHeadersWithConfig(DefaultSecureHeadersConfig)
+}
+
+// SecureHeadersWithConfig returns middleware that sets the configured security headers before
+// calling the next handler, which may still change them. When the Content-Security-Policy uses a
+// nonce, a new one is generated for every request and is available from Context.CSPNonce.
+func SecureHeadersWithConfig(config SecureHeadersConfig) MiddlewareFunc {
+	headers := make(map[string]string)
+	if config.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(config.HSTSMaxAge)
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	if config.ContentTypeNosniff {
+		headers["X-Content-Type-Options"] = "nosniff"
+	}
+	set := func(name, value string) {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	set("X-Frame-Options", config.FrameOptions)
+	set("Referrer-Policy", config.ReferrerPolicy)
+	set("Permissions-Policy", config.PermissionsPolicy)
+	set("Cross-Origin-Opener-Policy", config.CrossOriginOpenerPolicy)
+	set("Cross-Origin-Embedder-Policy", config.CrossOriginEmbedderPolicy)
+	set("Cross-Origin-Resource-Policy", config.CrossOriginResourcePolicy)
+
+	var policy *CSP
+	cspHeader := "Content-Security-Policy"
+	if config.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	if config.ContentSecurityPolicy != nil {
+		policy = config.ContentSecurityPolicy.clone()
+		if !policy.Has("frame-ancestors") {
+			switch strings.ToUpper(config.FrameOptions) {
+			case "DENY":
+				policy.Add("frame-ancestors", CSPNone)
+			case "SAMEORIGIN":
+				policy.Add("frame-ancestors", CSPSelf)
+			}
+		}
+		if !policy.usesNonce() {
+			headers[cspHeader] = policy.String("")
+			policy = nil
+		}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			header := c.Response.Header()
+			for name, value := range headers {
+				header.Set(name, value)
+			}
+			if policy != nil {
+				c.cspNonce = newCSPNonce()
+				header.Set(cspHeader, policy.String(c.cspNonce))
+			}
+			next(c)
+		}
+	}
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, or an empty string when
+// SecureHeaders is not used with a nonce policy. Use it in inline script and style tags:
+//
+//	c.HTML(http.StatusOK, `<script nonce="`+c.CSPNonce()+`">init()</script>`)
+func (c *Context) CSPNonce() string {
+	return c.cspNonce
+}
+
+// newCSPNonce returns a random base64 nonce with 128 bits of entropy.
+func newCSPNonce() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return base64.StdEncoding.EncodeToString(b[:])
+}
@@ -0,0 +1,109 @@
+package way
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecureHeadersDefaults(t *testing.T) {
+	w := New()
+	w.Use(SecureHeaders())
+	w.GET("/", func(c *Context) {
+		if c.CSPNonce() != "" {
+			t.Error("CSPNonce() is set for a policy without a nonce")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := map[string]string{
+		"Strict-Transport-Security":    "max-age=63072000; includeSubDomains",
+		"X-Content-Type-Options":       "nosniff",
+		"X-Frame-Options":              "DENY",
+		"Referrer-Policy":              "strict-origin-when-cross-origin",
+		"Permissions-Policy":           "camera=(), microphone=(), geolocation=()",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Resource-Policy": "same-origin",
+		"Content-Security-Policy":      "default-src 'self'; base-uri 'self'; object-src 'none'; frame-ancestors 'none'",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if got := rec.Header().Get("Cross-Origin-Embedder-Policy"); got != "" {
+		t.Errorf("Cross-Origin-Embedder-Policy = %q, want none", got)
+	}
+	if DefaultSecureHeadersConfig.ContentSecurityPolicy.Has("frame-ancestors") {
+		t.Fatal("SecureHeaders modified the default policy")
+	}
+}
+
+func TestSecureHeadersCSPNonce(t *testing.T) {
+	w := New()
+	w.Use(SecureHeadersWithConfig(SecureHeadersConfig{
+		FrameOptions: "SAMEORIGIN",
+		ContentSecurityPolicy: NewCSP().
+			Add("default-src", CSPSelf).
+			Add("script-src", CSPStrictDynamic).
+			Nonce("script-src", "style-src").
+			Add("upgrade-insecure-requests"),
+	}))
+	var nonces []string
+	w.GET("/", func(c *Context) {
+		nonces = append(nonces, c.CSPNonce())
+		c.HTML(http.StatusOK, `<script nonce="`+c.CSPNonce()+`"></script>`)
+	})
+
+	var policies []string
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		policies = append(policies, rec.Header().Get("Content-Security-Policy"))
+		if !strings.Contains(rec.Body.String(), nonces[i]) {
+			t.Fatalf("body %q does not contain the nonce", rec.Body.String())
+		}
+		if rec.Header().Get("Strict-Transport-Security") != "" {
+			t.Fatal("Strict-Transport-Security set without HSTSMaxAge")
+		}
+	}
+
+	if nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Fatalf("nonces = %q, want distinct non-empty values", nonces)
+	}
+	want := "default-src 'self'; script-src 'strict-dynamic' 'nonce-" + nonces[0] + "'; style-src 'self' 'nonce-" + nonces[0] +
+		"'; upgrade-insecure-requests; frame-ancestors 'self'"
+	if policies[0] != want {
+		t.Fatalf("Content-Security-Policy = %q, want %q", policies[0], want)
+	}
+}
+
+func TestSecureHeadersReportOnly(t *testing.T) {
+	w := New()
+	w.Use(SecureHeadersWithConfig(SecureHeadersConfig{
+		HSTSMaxAge:            31536000,
+		HSTSPreload:           true,
+		ContentSecurityPolicy: NewCSP().Add("default-src", CSPSelf).Add("report-to", "csp"),
+		CSPReportOnly:         true,
+	}))
+	w.GET("/", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("Content-Security-Policy-Report-Only"); got != "default-src 'self'; report-to csp" {
+		t.Fatalf("Content-Security-Policy-Report-Only = %q", got)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != "" {
+		t.Fatalf("Content-Security-Policy = %q, want none", got)
+	}
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; preload" {
+		t.Fatalf("Strict-Transport-Security = %q", got)
+	}
+}
@@ -27,8 +27,14 @@ type Session struct {
 	stores map[string]sessions.Store
 	// Map of secure cookies
 	cookies map[string]*securecookie.SecureCookie
+	// Observer of session store lookups
+	observer SessionObserver
 }
 
+// SessionObserver is called for every session store lookup with the store name
+// and whether the store was found.
+type SessionObserver func(store string, hit bool)
+
 func NewSession() *Session {
 	return &Session{
 		defaultStore:  "default",
@@ -54,20 +60,34 @@ func (w *Session) Store(name string) sessions.Store {
 	if w == nil {
 		return nil
 	}
-	return w.stores[name]
+	return w.lookupStore(name)
 }
 
 func (w *Session) StoreE(name string) (sessions.Store, error) {
 	if w == nil {
 		return nil, fmt.Errorf("%w: session manager is nil", ErrSessionStoreNotFound)
 	}
-	store := w.stores[name]
+	store := w.lookupStore(name)
 	if store == nil {
 		return nil, fmt.Errorf("%w: %s", ErrSessionStoreNotFound, name)
 	}
 	return store, nil
 }
 
+// SetObserver sets the observer called for every session store lookup, e.g. to record metrics.
+func (w *Session) SetObserver(observer SessionObserver) {
+	w.observer = observer
+}
+
+// lookupStore returns the named store and reports the lookup to the observer.
+func (w *Session) lookupStore(name string) sessions.Store {
+	store := w.stores[name]
+	if w.observer != nil {
+		w.observer(name, store != nil)
+	}
+	return store
+}
+
 func (w *Session) SetStore(name string, s sessions.Store) {
 	w.stores[name] = s
 }
@@ -110,7 +130,7 @@ func (w *Session) DefaultSession() sessions.Store {
 	if w == nil {
 		return nil
 	}
-	return w.stores[w.defaultStore]
+	return w.lookupStore(w.defaultStore)
 }
 
 func (w *Session) DefaultSessionE() (sessions.Store, error) {
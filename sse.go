@@ -0,0 +1,203 @@
+package way
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEHeartbeat is the interval of the heartbeat comments an EventStream sends to keep
+// idle connections open through proxies.
+var DefaultSSEHeartbeat = 15 * time.Second
+
+// ErrStreamClosed is returned by EventStream methods called after the stream was closed.
+var ErrStreamClosed = errors.New("event stream closed")
+
+// EventStream is a Server-Sent Events stream created by Context.SSE.
+// Its methods may be called from several goroutines.
+type EventStream struct {
+	c      *Context
+	rc     *http.ResponseController
+	ctx    context.Context
+	mu     sync.Mutex
+	closed bool
+	stop   chan struct{}
+	// ticker times heartbeats and is reset by every write.
+	ticker   *time.Ticker
+	interval time.Duration
+}
+
+// SSE starts a Server-Sent Events response and returns the stream to send events on.
+//
+// It sends the text/event-stream headers with a 200 status, removes the server's write deadline
+// so that WriteTimeout does not end long streams, and starts heartbeats every DefaultSSEHeartbeat.
+// The stream ends when the client disconnects, which closes Done and makes the send methods return
+// the request context error, and it is closed when the handler returns. Do not use the Timeout
+// middleware on SSE routes. Calling SSE again returns the same stream.
+//
+//	stream := c.SSE()
+//	for {
+//		select {
+//		case <-stream.Done():
+//			return
+//		case order := <-updates:
+//			if err := stream.SendJSON("order", order.ID, order); err != nil {
+//				return
+//			}
+//		}
+//	}
+func (c *Context) SSE() *EventStream {
+	if c.sse != nil {
+		return c.sse
+	}
+	s := &EventStream{
+		c:   c,
+		rc:  http.NewResponseController(c.Response),
+		ctx: c.Request.Context(),
+	}
+	c.sse = s
+
+	header := c.Response.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	if err := s.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		c.Slog().Warn("failed to remove the write deadline of the event stream", "error", err)
+	}
+	c.Response.WriteHeader(http.StatusOK)
+	if err := s.rc.Flush(); err != nil {
+		c.Slog().Error("failed to flush the event stream", "error", err)
+	}
+	s.Heartbeat(DefaultSSEHeartbeat)
+	return s
+}
+
+// Send sends an event with the given type, ID and data. An empty event type sends a message
+// event, and an empty id keeps the last event ID of the client. Data may span several lines.
+// The event type and ID must not contain line breaks.
+func (s *EventStream) Send(event, id, data string) error {
+	if strings.ContainsAny(event, "\r\n") || strings.ContainsAny(id, "\r\n\x00") {
+		return errors.New("way: event type and id must not contain line breaks")
+	}
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// SendJSON sends an event with v encoded as JSON as its data. See Send.
+func (s *EventStream) SendJSON(event, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(event, id, string(data))
+}
+
+// Retry tells the client to wait d before reconnecting after the connection is lost.
+func (s *EventStream) Retry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Comment sends a comment, which clients ignore. Line breaks in text are removed.
+func (s *EventStream) Comment(text string) error {
+	return s.write(": " + strings.NewReplacer("\r", "", "\n", " ").Replace(text) + "\n\n")
+}
+
+// Heartbeat sends a comment every interval while nothing else is sent, replacing the
+// DefaultSSEHeartbeat interval. An interval of zero or less stops heartbeats.
+func (s *EventStream) Heartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopHeartbeats()
+	if interval <= 0 || s.closed {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.ticker = time.NewTicker(interval)
+	s.interval = interval
+	go s.heartbeats(s.ticker, s.stop)
+}
+
+// heartbeats sends heartbeat comments until stop is closed or the client disconnects.
+func (s *EventStream) heartbeats(ticker *time.Ticker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(":\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// stopHeartbeats stops the heartbeat goroutine. s.mu must be held.
+func (s *EventStream) stopHeartbeats() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if s.ticker != nil {
+		s.ticker.Stop()
+		s.ticker = nil
+	}
+}
+
+// LastEventID returns the Last-Event-ID header a reconnecting client sends with the ID of the
+// last event it received, so that the handler can resume after it. It is empty on the first
+// connection.
+func (s *EventStream) LastEventID() string {
+	return s.c.Request.Header.Get("Last-Event-ID")
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Close stops heartbeats and ends the stream; later sends return ErrStreamClosed.
+// It is called when the handler of a route registered on Way returns.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.stopHeartbeats()
+}
+
+// write writes and flushes an event, and postpones the next heartbeat.
+func (s *EventStream) write(event string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if s.ticker != nil {
+		s.ticker.Reset(s.interval)
+	}
+	if _, err := s.c.Response.Write([]byte(event)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,196 @@
+package way
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvents reads n blank-line-terminated blocks from an event stream.
+func readEvents(t *testing.T, r *bufio.Reader, n int) []string {
+	t.Helper()
+	var events []string
+	var block strings.Builder
+	for len(events) < n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v (events so far %q)", err, events)
+		}
+		if line == "\n" {
+			events = append(events, block.String())
+			block.Reset()
+			continue
+		}
+		block.WriteString(line)
+	}
+	return events
+}
+
+func sseGet(t *testing.T, ctx context.Context, url string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSSESendsEvents(t *testing.T) {
+	w := New()
+	w.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		stream.Heartbeat(0)
+		_ = stream.Retry(3 * time.Second)
+		_ = stream.Send("greeting", "7", "hello\nworld")
+		_ = stream.SendJSON("", "8", map[string]string{"resume": stream.LastEventID()})
+		_ = stream.Comment("bye\nnow")
+		if err := stream.Send("bad", "1\n2", "x"); err == nil {
+			t.Error("Send() with a line break in the id succeeded")
+		}
+	})
+	server := httptest.NewServer(w)
+	defer server.Close()
+
+	resp := sseGet(t, context.Background(), server.URL+"/events", map[string]string{"Last-Event-ID": "6"})
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" || resp.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("headers = %v", resp.Header)
+	}
+	got := readEvents(t, bufio.NewReader(resp.Body), 4)
+	want := []string{
+		"retry: 3000\n",
+		"event: greeting\nid: 7\ndata: hello\ndata: world\n",
+		"id: 8\ndata: {\"resume\":\"6\"}\n",
+		": bye now\n",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSSEOutlivesWriteTimeoutWithHeartbeats(t *testing.T) {
+	w := New()
+	w.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		stream.Heartbeat(20 * time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+		_ = stream.Send("done", "", "ok")
+	})
+	server := httptest.NewUnstartedServer(w)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp := sseGet(t, context.Background(), server.URL+"/events", nil)
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	heartbeats := 0
+	for {
+		event := readEvents(t, r, 1)[0]
+		if event == ":\n" {
+			heartbeats++
+			continue
+		}
+		if event != "event: done\ndata: ok\n" {
+			t.Fatalf("event = %q", event)
+		}
+		break
+	}
+	if heartbeats == 0 {
+		t.Fatal("no heartbeats were sent")
+	}
+}
+
+func TestSSEHeartbeatOnlyWhenIdle(t *testing.T) {
+	w := New()
+	w.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		stream.Heartbeat(40 * time.Millisecond)
+		for i := 0; i < 15; i++ {
+			_ = stream.Send("", "", "tick")
+			time.Sleep(10 * time.Millisecond)
+		}
+		_ = stream.Send("done", "", "ok")
+	})
+	server := httptest.NewServer(w)
+	defer server.Close()
+
+	resp := sseGet(t, context.Background(), server.URL+"/events", nil)
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	for {
+		event := readEvents(t, r, 1)[0]
+		if event == ":\n" {
+			t.Fatal("heartbeat sent while events were being sent")
+		}
+		if event == "event: done\ndata: ok\n" {
+			break
+		}
+	}
+}
+
+func TestSSEStopsWhenClientDisconnects(t *testing.T) {
+	w := New()
+	stopped := make(chan error, 1)
+	w.GET("/events", func(c *Context) {
+		stream := c.SSE()
+		_ = stream.Send("", "", "first")
+		<-stream.Done()
+		stopped <- stream.Send("", "", "late")
+	})
+	server := httptest.NewServer(w)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := sseGet(t, ctx, server.URL+"/events", nil)
+	readEvents(t, bufio.NewReader(resp.Body), 1)
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Send() after disconnect error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not observe the disconnect")
+	}
+}
+
+func TestSSEClosedWhenHandlerReturns(t *testing.T) {
+	w := New()
+	var stream *EventStream
+	w.GET("/events", func(c *Context) {
+		stream = c.SSE()
+		if c.SSE() != stream {
+			t.Error("SSE() returned a new stream")
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Fatalf("status = %d, flushed = %v", rec.Code, rec.Flushed)
+	}
+	if err := stream.Send("", "", "late"); !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("Send() after the handler returned error = %v, want ErrStreamClosed", err)
+	}
+}
@@ -0,0 +1,210 @@
+package way
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrRequestTimeout is wrapped by the error the Timeout middleware passes to the error handler.
+var ErrRequestTimeout = errors.New("request timed out")
+
+// Timeout returns middleware that cancels the request context after d.
+//
+// Handlers must pass c.Request.Context() to blocking calls, such as the DB query helpers and
+// outbound requests, so that they are cancelled at the deadline; the handler itself is not
+// interrupted. Like http.TimeoutHandler, a response the handler has not started before the
+// deadline is discarded, along with the headers it set, and writes return http.ErrHandlerTimeout.
+// When the handler returns, a 503 *HTTPError wrapping ErrRequestTimeout is passed to the error
+// handler, unless the handler passed its own error to Context.Error after the deadline. Errors
+// returned after a call was cancelled at the deadline wrap context.DeadlineExceeded and are sent
+// as 504 responses. A response started before the deadline is completed.
+//
+// Timeout can be used globally with Use and per route. A later Timeout replaces the deadline
+// of an earlier one, so a route can have a longer or shorter deadline than the global one.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if d <= 0 {
+				next(c)
+				return
+			}
+			parent := c.Request.Context()
+			var ctx context.Context
+			var cancel context.CancelFunc
+			tw := c.timeout
+			if tw == nil {
+				ctx, cancel = context.WithTimeout(parent, d)
+				tw = &timeoutWriter{
+					ResponseWriter: c.Response,
+					base:           parent,
+					header:         c.Response.Header().Clone(),
+					original:       c.Response.Header().Clone(),
+				}
+				c.timeout = tw
+				c.Response = tw
+				defer func() {
+					c.timeout = nil
+					c.Response = tw.ResponseWriter
+				}()
+			} else {
+				// Keep the request values but not the earlier deadline, while still
+				// cancelling when the client goes away.
+				ctx, cancel = context.WithTimeout(context.WithoutCancel(parent), d)
+				stop := context.AfterFunc(tw.base, cancel)
+				defer stop()
+				outer := tw.ctx
+				defer func() { tw.ctx = outer }()
+			}
+			defer cancel()
+			tw.ctx = ctx
+
+			c.Request = c.Request.WithContext(ctx)
+			next(c)
+			c.Request = c.Request.WithContext(parent)
+
+			// The innermost Timeout, whose deadline applied to the handler, decides.
+			if tw.finished {
+				return
+			}
+			tw.finished = true
+			if tw.timedOut() {
+				if rw := c.writer; rw != nil && rw.written {
+					// A buffering writer, such as the compression one, reported the discarded
+					// response as written.
+					rw.written = false
+					rw.status = 0
+				}
+				c.Error(NewHTTPError(http.StatusServiceUnavailable, "request timed out").WithCode("timeout").Wrap(ErrRequestTimeout))
+			}
+		}
+	}
+}
+
+// timeoutWriter discards the response a handler writes after the Timeout deadline.
+// Headers are kept apart until the response starts, so that late changes can be dropped.
+type timeoutWriter struct {
+	http.ResponseWriter
+	// base is the request context before the first Timeout middleware.
+	base context.Context
+	// ctx is the request context with the deadline in effect.
+	ctx context.Context
+	// header holds the headers set before the response starts.
+	header http.Header
+	// original holds the headers set before the first Timeout middleware.
+	original http.Header
+	// started reports whether the response started before the deadline.
+	started bool
+	// errorResponse reports whether Context.Error was called after the deadline, so that the
+	// error response is written.
+	errorResponse bool
+	// finished reports whether a Timeout middleware handled the end of the handler.
+	finished bool
+}
+
+// timedOut reports whether the deadline passed before the response started.
+func (w *timeoutWriter) timedOut() bool {
+	return !w.started && w.ctx != nil && errors.Is(w.ctx.Err(), context.DeadlineExceeded)
+}
+
+// discard reports whether writes are dropped.
+func (w *timeoutWriter) discard() bool {
+	return !w.errorResponse && w.timedOut()
+}
+
+// handleError lets the error response for an error passed to Context.Error after the deadline be
+// written, without the headers the handler set.
+func (w *timeoutWriter) handleError() {
+	if w.errorResponse || !w.timedOut() {
+		return
+	}
+	w.errorResponse = true
+	w.header = w.original.Clone()
+}
+
+// Header returns the headers of the response.
+func (w *timeoutWriter) Header() http.Header {
+	if w.started {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+// WriteHeader sends the headers and status code unless the deadline has passed.
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.discard() {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if !w.started {
+		clear(header)
+		for name, values := range w.header {
+			header[name] = values
+		}
+		w.started = code >= http.StatusOK || code == http.StatusSwitchingProtocols
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the data, or returns http.ErrHandlerTimeout once the deadline has passed.
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.discard() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// ReadFrom copies from r, using the underlying writer's io.ReaderFrom when available.
+func (w *timeoutWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.discard() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(writerOnly{w.ResponseWriter}, r)
+}
+
+// Flush sends any buffered data to the client.
+func (w *timeoutWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError is Flush reporting whether the underlying writer supports flushing.
+// It is used by http.ResponseController.
+func (w *timeoutWriter) FlushError() error {
+	if w.discard() {
+		return http.ErrHandlerTimeout
+	}
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection.
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.discard() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.started = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,139 @@
+package way
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func timeoutProblem(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d", rec.Code, status)
+	}
+	var body Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Extensions["code"] != "timeout" {
+		t.Fatalf("code = %v, want timeout", body.Extensions["code"])
+	}
+}
+
+func TestTimeoutCancelledCallReturnsGatewayTimeout(t *testing.T) {
+	w := New()
+	w.Use(Timeout(10 * time.Millisecond))
+	w.GET("/slow", E(func(c *Context) error {
+		<-c.Request.Context().Done()
+		return c.Request.Context().Err()
+	}))
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	timeoutProblem(t, rec, http.StatusGatewayTimeout)
+}
+
+func TestTimeoutUnwrittenResponseReturnsServiceUnavailable(t *testing.T) {
+	w := New()
+	var handlerErr error
+	w.SetErrorHandler(func(c *Context, err error) {
+		handlerErr = err
+		DefaultErrorHandler(c, err)
+	})
+	w.Use(Timeout(10 * time.Millisecond))
+	w.GET("/slow", func(c *Context) {
+		<-c.Request.Context().Done()
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	timeoutProblem(t, rec, http.StatusServiceUnavailable)
+	if !errors.Is(handlerErr, ErrRequestTimeout) {
+		t.Fatalf("error = %v, want ErrRequestTimeout", handlerErr)
+	}
+}
+
+func TestTimeoutDiscardsLateResponse(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		w := New()
+		w.Use(RequestID(RequestIDConfig{}))
+		w.Use(Timeout(10 * time.Millisecond))
+		if compress {
+			w.Use(Compress())
+		}
+		var writeErr error
+		w.GET("/slow", func(c *Context) {
+			time.Sleep(30 * time.Millisecond)
+			c.SetHeader("X-Late", "1")
+			_, writeErr = c.Response.Write([]byte(strings.Repeat("late ", 512)))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		w.ServeHTTP(rec, req)
+
+		timeoutProblem(t, rec, http.StatusServiceUnavailable)
+		if rec.Header().Get("X-Late") != "" || rec.Header().Get(DefaultRequestIDHeader) == "" {
+			t.Fatalf("compress %v: headers = %v", compress, rec.Header())
+		}
+		if !compress && !errors.Is(writeErr, http.ErrHandlerTimeout) {
+			t.Fatalf("late Write() error = %v, want http.ErrHandlerTimeout", writeErr)
+		}
+	}
+}
+
+func TestTimeoutPerRouteOverridesGlobal(t *testing.T) {
+	w := New()
+	w.Use(Timeout(10 * time.Millisecond))
+	w.GET("/report", func(c *Context) {
+		select {
+		case <-c.Request.Context().Done():
+			c.String(http.StatusServiceUnavailable, "cancelled")
+		case <-time.After(50 * time.Millisecond):
+			c.String(http.StatusOK, "done")
+		}
+	}, Timeout(time.Second))
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "done" {
+		t.Fatalf("response = %d %q, want 200 done", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeoutFastHandlerAndRestoredContext(t *testing.T) {
+	w := New()
+	var restored bool
+	w.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			parent := c.Request.Context()
+			next(c)
+			restored = c.Request.Context() == parent
+		}
+	})
+	w.Use(Timeout(time.Second))
+	w.GET("/fast", func(c *Context) {
+		if _, ok := c.Request.Context().Deadline(); !ok {
+			t.Error("request context has no deadline")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !restored {
+		t.Fatal("request context was not restored after the handler")
+	}
+}
@@ -0,0 +1,16 @@
+module github.com/swayedev/way/trace/otel
+
+go 1.26.0
+
+require (
+	github.com/swayedev/way v0.0.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
+
+replace (
+	github.com/swayedev/fcrypt => ../../../fcrypt
+	github.com/swayedev/way => ../..
+)
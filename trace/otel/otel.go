@@ -0,0 +1,138 @@
+// Package otel adapts an OpenTelemetry tracer to the Way trace.Tracer interface.
+//
+//	w.SetTracer(otel.NewTracer(otelapi.Tracer("my-service")))
+//
+// Spans are started with the OpenTelemetry tracer, so they are exported by the configured
+// OpenTelemetry SDK and nest with spans created by other instrumented libraries.
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/swayedev/way/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// NewTracer returns a trace.Tracer that starts spans with tracer.
+func NewTracer(tracer oteltrace.Tracer) trace.Tracer {
+	return &otelTracer{tracer: tracer}
+}
+
+// otelTracer is the trace.Tracer returned by NewTracer.
+type otelTracer struct {
+	tracer oteltrace.Tracer
+}
+
+// Start starts an OpenTelemetry span. A remote parent extracted by Way is converted
+// to an OpenTelemetry remote span context when ctx carries no OpenTelemetry span.
+func (t *otelTracer) Start(ctx context.Context, name string, options ...trace.StartOption) (context.Context, trace.Span) {
+	config := trace.NewStartConfig(options...)
+	if !oteltrace.SpanContextFromContext(ctx).IsValid() {
+		if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+			ctx = oteltrace.ContextWithRemoteSpanContext(ctx, toOtel(parent))
+		}
+	}
+	attrs := make([]attribute.KeyValue, 0, len(config.Attributes))
+	for key, value := range config.Attributes {
+		attrs = append(attrs, toAttribute(key, value))
+	}
+	ctx, span := t.tracer.Start(ctx, name, oteltrace.WithSpanKind(toSpanKind(config.Kind)), oteltrace.WithAttributes(attrs...))
+	s := &otelSpan{span: span, tracer: t}
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// otelSpan adapts an OpenTelemetry span to trace.Span.
+type otelSpan struct {
+	span   oteltrace.Span
+	tracer *otelTracer
+}
+
+func (s *otelSpan) SpanContext() trace.SpanContext {
+	return fromOtel(s.span.SpanContext())
+}
+
+func (s *otelSpan) Tracer() trace.Tracer {
+	return s.tracer
+}
+
+func (s *otelSpan) SetName(name string) {
+	s.span.SetName(name)
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(toAttribute(key, value))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+// toOtel converts a Way span context to an OpenTelemetry span context.
+func toOtel(sc trace.SpanContext) oteltrace.SpanContext {
+	config := oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID(sc.TraceID),
+		SpanID:     oteltrace.SpanID(sc.SpanID),
+		TraceFlags: oteltrace.TraceFlags(sc.Flags),
+		Remote:     sc.Remote,
+	}
+	if state, err := oteltrace.ParseTraceState(sc.TraceState); err == nil {
+		config.TraceState = state
+	}
+	return oteltrace.NewSpanContext(config)
+}
+
+// fromOtel converts an OpenTelemetry span context to a Way span context.
+func fromOtel(sc oteltrace.SpanContext) trace.SpanContext {
+	return trace.SpanContext{
+		TraceID:    trace.TraceID(sc.TraceID()),
+		SpanID:     trace.SpanID(sc.SpanID()),
+		Flags:      byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+		Remote:     sc.IsRemote(),
+	}
+}
+
+// toSpanKind converts a Way span kind to an OpenTelemetry span kind.
+func toSpanKind(kind trace.SpanKind) oteltrace.SpanKind {
+	switch kind {
+	case trace.SpanKindServer:
+		return oteltrace.SpanKindServer
+	case trace.SpanKindClient:
+		return oteltrace.SpanKindClient
+	default:
+		return oteltrace.SpanKindInternal
+	}
+}
+
+// toAttribute converts an attribute value, formatting unsupported types as strings.
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
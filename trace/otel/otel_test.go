@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/swayedev/way/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracerContinuesRemoteParent(t *testing.T) {
+	parent, err := trace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error = %v", err)
+	}
+	parent.TraceState = "vendor=value"
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	tracer := NewTracer(noop.NewTracerProvider().Tracer("test"))
+	ctx, span := tracer.Start(ctx, "GET /users", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	// The no-op OpenTelemetry tracer returns the parent span context, so the
+	// conversion to OpenTelemetry and back must preserve it.
+	got := span.SpanContext()
+	if got.TraceID != parent.TraceID || got.SpanID != parent.SpanID || !got.IsSampled() || got.TraceState != "vendor=value" {
+		t.Fatalf("SpanContext() = %+v, want %+v", got, parent)
+	}
+	if trace.SpanFromContext(ctx) != span {
+		t.Fatal("SpanFromContext() did not return the started span")
+	}
+	if _, child := trace.Start(ctx, "SELECT sqlite3"); child.Tracer() != tracer {
+		t.Fatal("child span was not started with the adapter")
+	}
+}
+
+func TestToAttributeConvertsValues(t *testing.T) {
+	tests := map[string]struct {
+		value interface{}
+		want  attribute.KeyValue
+	}{
+		"string":  {"GET", attribute.String("k", "GET")},
+		"int":     {200, attribute.Int("k", 200)},
+		"bool":    {true, attribute.Bool("k", true)},
+		"float64": {1.5, attribute.Float64("k", 1.5)},
+		"other":   {uint8(7), attribute.String("k", "7")},
+	}
+	for name, test := range tests {
+		if got := toAttribute("k", test.value); got != test.want {
+			t.Fatalf("%s: toAttribute() = %v, want %v", name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context header names.
+const (
+	TraceparentHeader = "Traceparent"
+	TracestateHeader  = "Tracestate"
+)
+
+// maxTracestateLength is the longest tracestate value propagated; longer values are dropped.
+const maxTracestateLength = 512
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for malformed headers.
+var ErrInvalidTraceparent = errors.New("trace: invalid traceparent")
+
+// ParseTraceparent parses a traceparent header value of the form
+// version-traceid-spanid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+// Versions other than 00 are accepted as long as the known fields parse, as the specification requires.
+func ParseTraceparent(value string) (SpanContext, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	version, err := parseHexByte(value[0:2])
+	if err != nil || version == 0xff || (version == 0 && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	var sc SpanContext
+	if !decodeLowerHex(sc.TraceID[:], value[3:35]) || !decodeLowerHex(sc.SpanID[:], value[36:52]) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if sc.Flags, err = parseHexByte(value[53:55]); err != nil || !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// Traceparent formats the span context as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// Extract reads the traceparent and tracestate headers.
+// It reports false when traceparent is missing or invalid, in which case tracestate is ignored.
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	state := strings.Join(header.Values(TracestateHeader), ",")
+	if len(state) <= maxTracestateLength {
+		sc.TraceState = strings.TrimSpace(state)
+	}
+	sc.Remote = true
+	return sc, true
+}
+
+// Inject writes the traceparent and tracestate headers for the span context in ctx.
+// It does nothing when ctx carries no valid span context.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// parseHexByte parses two lower-case hex characters.
+func parseHexByte(s string) (byte, error) {
+	var b [1]byte
+	if !decodeLowerHex(b[:], s) {
+		return 0, ErrInvalidTraceparent
+	}
+	return b[0], nil
+}
+
+// decodeLowerHex decodes s into dst, rejecting upper-case hex as the specification requires.
+func decodeLowerHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
@@ -0,0 +1,220 @@
+// Package trace provides W3C Trace Context propagation and a minimal tracing API for Way.
+//
+// Way creates a server span for every request, and child spans around database calls and
+// outbound requests, through the Tracer set with Way.SetTracer. Use NewTracer with an Exporter
+// for a dependency-free tracer, or the trace/otel package to send spans to OpenTelemetry.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// String returns the trace ID as 32 lower-case hex characters.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the trace ID is not all zeros.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the span ID as 16 lower-case hex characters.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether the span ID is not all zeros.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// FlagsSampled is the trace flag that marks a trace as sampled.
+const FlagsSampled byte = 0x01
+
+// SpanContext is the propagated identity of a span.
+// TraceState holds the vendor-specific tracestate header value.
+// Remote is set for span contexts extracted from incoming requests.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	Remote     bool
+}
+
+// IsValid reports whether both the trace ID and span ID are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled reports whether the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagsSampled != 0
+}
+
+// SpanKind describes the relationship of a span to its parent and children.
+type SpanKind int
+
+const (
+	// SpanKindInternal is an operation within the application.
+	SpanKindInternal SpanKind = iota
+	// SpanKindServer handles an incoming request.
+	SpanKindServer
+	// SpanKindClient makes an outbound request, such as a database query or HTTP call.
+	SpanKindClient
+)
+
+// String returns the name of the span kind.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// Tracer starts spans.
+// Start returns a copy of ctx that carries the new span, so spans started from it become its children.
+// The parent is the span in ctx, or else a remote span context set with ContextWithRemoteSpanContext.
+type Tracer interface {
+	Start(ctx context.Context, name string, options ...StartOption) (context.Context, Span)
+}
+
+// Span is a timed operation within a trace. Span methods are safe for concurrent use,
+// and calls after End have no effect.
+type Span interface {
+	// SpanContext returns the propagated identity of the span.
+	SpanContext() SpanContext
+	// Tracer returns the tracer that started the span, used to start children.
+	Tracer() Tracer
+	// SetName replaces the span name, e.g. once the route template is known.
+	SetName(name string)
+	// SetAttribute sets an attribute. Values should be strings, bools, integers or floats.
+	SetAttribute(key string, value interface{})
+	// RecordError records err and marks the span as failed.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+// StartConfig holds the options of Tracer.Start. Tracer implementations read it with NewStartConfig.
+type StartConfig struct {
+	Kind       SpanKind
+	Attributes map[string]interface{}
+}
+
+// StartOption configures a span started with Tracer.Start.
+type StartOption func(*StartConfig)
+
+// WithSpanKind sets the kind of the span, SpanKindInternal by default.
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(config *StartConfig) {
+		config.Kind = kind
+	}
+}
+
+// WithAttribute sets an attribute when the span starts.
+func WithAttribute(key string, value interface{}) StartOption {
+	return func(config *StartConfig) {
+		if config.Attributes == nil {
+			config.Attributes = make(map[string]interface{})
+		}
+		config.Attributes[key] = value
+	}
+}
+
+// NewStartConfig applies the options.
+func NewStartConfig(options ...StartOption) StartConfig {
+	var config StartConfig
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// spanKey and remoteKey are the context keys for the current span and remote span context.
+type (
+	spanKey   struct{}
+	remoteKey struct{}
+)
+
+// ContextWithSpan returns a copy of ctx that carries span.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or a no-op span.
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx that carries a span context extracted from a request.
+// Tracers use it as the parent when ctx carries no span.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the span in ctx, or else the remote span context.
+// The result is not valid when ctx carries neither.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Start starts a child of the span in ctx with the tracer that started it.
+// When ctx carries no span, it returns ctx and a no-op span, so callers such as
+// database helpers only create spans within a traced request.
+func Start(ctx context.Context, name string, options ...StartOption) (context.Context, Span) {
+	parent, ok := ctx.Value(spanKey{}).(Span)
+	if !ok || parent.Tracer() == nil {
+		return ctx, noopSpan{}
+	}
+	return parent.Tracer().Start(ctx, name, options...)
+}
+
+// noopSpan is returned when there is nothing to trace.
+type noopSpan struct{}
+
+func (noopSpan) SpanContext() SpanContext         { return SpanContext{} }
+func (noopSpan) Tracer() Tracer                   { return nil }
+func (noopSpan) SetName(string)                   {}
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+
+// newTraceID returns a random trace ID.
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// newSpanID returns a random span ID.
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
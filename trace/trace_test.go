@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error = %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.IsSampled() {
+		t.Fatalf("ParseTraceparent() = %+v", sc)
+	}
+	if got := sc.Traceparent(); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("Traceparent() = %q", got)
+	}
+}
+
+func TestParseTraceparentRejectsInvalidValues(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(value); !errors.Is(err, ErrInvalidTraceparent) {
+			t.Fatalf("ParseTraceparent(%q) error = %v, want ErrInvalidTraceparent", value, err)
+		}
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"); err != nil {
+		t.Fatalf("ParseTraceparent(future version) error = %v", err)
+	}
+}
+
+func TestExtractAndInject(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Add(TracestateHeader, "rojo=00f067aa0ba902b7")
+	header.Add(TracestateHeader, "congo=t61rcWkgMzE")
+
+	parent, ok := Extract(header)
+	if !ok || !parent.Remote || parent.TraceState != "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE" {
+		t.Fatalf("Extract() = %+v, %v", parent, ok)
+	}
+
+	exporter := NewInMemoryExporter()
+	ctx := ContextWithRemoteSpanContext(context.Background(), parent)
+	ctx, span := NewTracer(exporter).Start(ctx, "request")
+
+	out := http.Header{}
+	Inject(ctx, out)
+	child, ok := Extract(out)
+	if !ok || child.TraceID != parent.TraceID || child.SpanID != span.SpanContext().SpanID || child.TraceState != parent.TraceState {
+		t.Fatalf("injected span context = %+v", child)
+	}
+}
+
+func TestTracerExportsSampledSpans(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root", WithSpanKind(SpanKindServer), WithAttribute("http.route", "/users"))
+	_, child := Start(ctx, "child", WithSpanKind(SpanKindClient))
+	child.RecordError(errors.New("query failed"))
+	child.End()
+	root.SetAttribute("http.response.status_code", 500)
+	root.End()
+	root.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	if spans[0].Name != "child" || spans[0].Parent != root.SpanContext().SpanID || spans[0].SpanContext.TraceID != root.SpanContext().TraceID || spans[0].Err == nil {
+		t.Fatalf("child span = %+v", spans[0])
+	}
+	if spans[1].Kind != SpanKindServer || spans[1].Attributes["http.route"] != "/users" || spans[1].Attributes["http.response.status_code"] != 500 {
+		t.Fatalf("root span = %+v", spans[1])
+	}
+}
+
+func TestTracerRespectsUnsampledParent(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx := ContextWithRemoteSpanContext(context.Background(), parent)
+
+	_, span := NewTracer(exporter).Start(ctx, "request")
+	span.End()
+
+	if len(exporter.Spans()) != 0 {
+		t.Fatalf("exported %d spans for an unsampled trace", len(exporter.Spans()))
+	}
+}
+
+func TestStartWithoutSpanIsNoop(t *testing.T) {
+	ctx := context.Background()
+	got, span := Start(ctx, "orphan")
+	if got != ctx || span.SpanContext().IsValid() {
+		t.Fatal("Start() without a parent span created a span")
+	}
+}
@@ -0,0 +1,162 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanData is a completed span passed to an Exporter.
+// Parent is the span ID of the parent span, not valid for root spans.
+// Err is the last error recorded with RecordError.
+type SpanData struct {
+	Name        string
+	Kind        SpanKind
+	SpanContext SpanContext
+	Parent      SpanID
+	Start       time.Time
+	End         time.Time
+	Attributes  map[string]interface{}
+	Err         error
+}
+
+// Exporter receives sampled spans when they end. ExportSpan must be safe for concurrent use.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// NewTracer returns a tracer that exports sampled spans to exporter.
+// Root spans are sampled; spans with a parent follow the parent's sampled flag,
+// so upstream sampling decisions received in traceparent are respected.
+func NewTracer(exporter Exporter) Tracer {
+	return &tracer{exporter: exporter}
+}
+
+// tracer is the Tracer returned by NewTracer.
+type tracer struct {
+	exporter Exporter
+}
+
+// Start starts a span as a child of the span or remote span context in ctx.
+func (t *tracer) Start(ctx context.Context, name string, options ...StartOption) (context.Context, Span) {
+	config := NewStartConfig(options...)
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Flags = FlagsSampled
+	}
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        config.Kind,
+			SpanContext: sc,
+			Parent:      parent.SpanID,
+			Start:       time.Now(),
+			Attributes:  config.Attributes,
+		},
+	}
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+// span is the Span created by tracer.
+type span struct {
+	tracer *tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *span) Tracer() Tracer {
+	return s.tracer
+}
+
+func (s *span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Name = name
+	}
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Err = err
+	}
+}
+
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for key, value := range s.data.Attributes {
+		data.Attributes[key] = value
+	}
+	s.mu.Unlock()
+	if data.SpanContext.IsSampled() && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// InMemoryExporter stores exported spans in memory, for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter returns an empty InMemoryExporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan stores span.
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset removes all exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,75 @@
+package way
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/swayedev/way/trace"
+)
+
+// SetTracer sets the tracer used for request, database and outbound request spans.
+// Tracing is disabled when no tracer is set, which is the default.
+func (w *Way) SetTracer(tracer trace.Tracer) {
+	w.tracer = tracer
+}
+
+// Tracer returns the tracer set with SetTracer, or nil.
+func (w *Way) Tracer() trace.Tracer {
+	return w.tracer
+}
+
+// Span returns the current span of the request, or a no-op span when tracing is disabled.
+// Use trace.Start with c.Request.Context() to start child spans.
+func (c *Context) Span() trace.Span {
+	return trace.SpanFromContext(c.Request.Context())
+}
+
+// traceRequest starts a server span around next, continuing the trace from the
+// traceparent and tracestate request headers. The span is named after the matched route template.
+func (w *Way) traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		if w.tracer == nil {
+			next.ServeHTTP(wr, r)
+			return
+		}
+		c := w.acquireContext(wr, r)
+		ctx := c.Request.Context()
+		if parent, ok := trace.Extract(r.Header); ok {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+		}
+		ctx, span := w.tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttribute("http.request.method", r.Method),
+			trace.WithAttribute("url.path", r.URL.Path),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		next.ServeHTTP(c.Response, c.Request)
+
+		if route := RouteTemplate(c.Request); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttribute("http.route", route)
+		}
+		status := accessLogStatus(c)
+		span.SetAttribute("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(status)))
+		}
+	})
+}
+
+// startDBSpan starts a client span for a database call within a traced request.
+func (d *DB) startDBSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return trace.Start(ctx, strings.TrimSpace(operation+" "+d.Driver), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttribute("db.system.name", d.Driver),
+		trace.WithAttribute("db.operation.name", operation),
+	)
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.End()
+}
@@ -0,0 +1,146 @@
+package way
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/swayedev/way/database/drivers/sqlite"
+	"github.com/swayedev/way/trace"
+)
+
+func TestTracingCreatesServerAndChildSpans(t *testing.T) {
+	var upstreamParent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamParent = r.Header.Get(trace.TraceparentHeader)
+		w.Write([]byte("media"))
+	}))
+	defer upstream.Close()
+
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer conn.Close()
+	db := NewDB()
+	db.SQLNew(conn, "sqlite3")
+
+	exporter := trace.NewInMemoryExporter()
+	w := New()
+	w.SetDB(&db)
+	w.SetTracer(trace.NewTracer(exporter))
+	w.GET("/users/{id}", func(c *Context) {
+		if _, err := c.GetDB().SQLExec(c.Request.Context(), "CREATE TABLE users (id INTEGER)"); err != nil {
+			t.Errorf("SQLExec() error = %v", err)
+		}
+		c.ProxyMedia(upstream.URL)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(trace.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3: %+v", len(spans), spans)
+	}
+	dbSpan, proxySpan, server := spans[0], spans[1], spans[2]
+	if server.Name != "GET /users/{id}" || server.Kind != trace.SpanKindServer || server.Parent.String() != "00f067aa0ba902b7" {
+		t.Fatalf("server span = %+v", server)
+	}
+	if server.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Attributes["http.response.status_code"] != http.StatusOK {
+		t.Fatalf("server span = %+v", server)
+	}
+	if dbSpan.Name != "CREATE sqlite3" || dbSpan.Parent != server.SpanContext.SpanID || dbSpan.Attributes["db.system.name"] != "sqlite3" {
+		t.Fatalf("db span = %+v", dbSpan)
+	}
+	if proxySpan.Kind != trace.SpanKindClient || proxySpan.Parent != server.SpanContext.SpanID {
+		t.Fatalf("proxy span = %+v", proxySpan)
+	}
+	if want := proxySpan.SpanContext.Traceparent(); upstreamParent != want {
+		t.Fatalf("upstream traceparent = %q, want %q", upstreamParent, want)
+	}
+}
+
+func TestTracingEndsQueryRowSpansOnce(t *testing.T) {
+	conn, err := sql.Open("sqlite3", "file:queryrow?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer conn.Close()
+	// The unscanned row keeps its connection, so the connections share the database.
+	db := NewDB()
+	db.SQLNew(conn, "sqlite3")
+	var observed []error
+	db.SetQueryObserver(func(driver, operation string, duration time.Duration, err error) {
+		observed = append(observed, err)
+	})
+
+	exporter := trace.NewInMemoryExporter()
+	w := New()
+	w.SetDB(&db)
+	w.SetTracer(trace.NewTracer(exporter))
+	w.GET("/users", func(c *Context) {
+		ctx := c.Request.Context()
+		if _, err := c.GetDB().SQLExec(ctx, "CREATE TABLE users (id INTEGER)"); err != nil {
+			t.Errorf("SQLExec() error = %v", err)
+		}
+		// A row that is never scanned.
+		c.GetDB().SQLQueryRow(ctx, "SELECT id FROM users")
+		var id int
+		if err := c.GetDB().SQLQueryRow(ctx, "SELECT id FROM users").Scan(&id); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Scan() error = %v, want sql.ErrNoRows", err)
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	w.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	spans := exporter.Spans()
+	if len(spans) != 4 {
+		t.Fatalf("exported %d spans, want 4: %+v", len(spans), spans)
+	}
+	for _, span := range spans[1:3] {
+		if span.Name != "SELECT sqlite3" || span.Err != nil {
+			t.Fatalf("query row span = %+v, want a successful SELECT span", span)
+		}
+	}
+	if len(observed) != 3 || observed[1] != nil || observed[2] != nil {
+		t.Fatalf("observed errors = %v, want 3 calls with successful query rows", observed)
+	}
+}
+
+func TestTracingRecordsServerErrors(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	w := New()
+	w.SetTracer(trace.NewTracer(exporter))
+	w.GET("/fail", func(c *Context) {
+		c.Status(http.StatusBadGateway)
+	})
+
+	w.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	spans := exporter.Spans()
+	if len(spans) != 1 || spans[0].Err == nil || spans[0].Parent.IsValid() {
+		t.Fatalf("spans = %+v, want one failed root span", spans)
+	}
+}
+
+func TestServeHTTPWithoutTracer(t *testing.T) {
+	w := New()
+	w.GET("/ok", func(c *Context) {
+		if c.Span().SpanContext().IsValid() {
+			t.Error("span created without a tracer")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
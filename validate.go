@@ -0,0 +1,381 @@
+package way
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes a field that failed validation.
+// Field is the path of the field, using json tag names where present, e.g. "items[0].name".
+// Rule is the validation rule that failed and Param is its parameter, if any.
+// Message is a human-readable description of the failure.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error returns the field path and message.
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors is returned by Validate when one or more fields fail validation.
+// The default error handler renders it as a 422 response listing each failing field.
+type ValidationErrors []FieldError
+
+// Error returns the messages of all failing fields.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// validationRule is a single parsed rule of a validate struct tag.
+type validationRule struct {
+	name  string
+	param string
+}
+
+var (
+	// ruleCache caches parsed validate tags.
+	ruleCache sync.Map
+	// regexpCache caches compiled regexp rule patterns.
+	regexpCache sync.Map
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Validate validates the struct v using `validate` struct tags.
+// It returns ValidationErrors when fields fail validation, or another error when a tag is invalid.
+//
+// Rules are separated by commas:
+//
+//	required        the value must not be the zero value
+//	omitempty       skip the remaining rules when the value is the zero value
+//	min=N, max=N    minimum/maximum number, string length in characters, or number of items
+//	len=N           exact string length in characters or number of items
+//	email           a valid email address
+//	url             an absolute URL with a scheme and host
+//	oneof=a b c     one of the space separated values
+//	uuid            a UUID in canonical form
+//	regexp=PATTERN  matches PATTERN; it must be the last rule, since the pattern may contain commas
+//	dive            apply the following rules to each element of a slice, array or map
+//
+// Nested structs and pointers to structs are validated recursively. Elements of
+// slices and maps are only validated when the field uses dive.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fmt.Errorf("way: cannot validate nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("way: cannot validate %T, want a struct", v)
+	}
+	var errs ValidationErrors
+	if err := validateStruct("", rv, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate validates v, see the package-level Validate function.
+func (c *Context) Validate(v interface{}) error {
+	return Validate(v)
+}
+
+// BindAndValidate binds the request into dst with Bind and then validates it.
+// Validation failures are returned as ValidationErrors.
+func (c *Context) BindAndValidate(dst interface{}) error {
+	if err := c.Bind(dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// validateStruct validates the fields of the struct value v.
+func validateStruct(path string, v reflect.Value, errs *ValidationErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		rules, err := parseRules(tag)
+		if err != nil {
+			return fmt.Errorf("way: field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinFieldPath(path, fieldName(field))
+		}
+		if err := validateField(fieldPath, v.Field(i), rules, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateField applies rules to v and recurses into nested structs.
+func validateField(path string, v reflect.Value, rules []validationRule, errs *ValidationErrors) error {
+	for i, rule := range rules {
+		switch rule.name {
+		case "omitempty":
+			if v.IsZero() {
+				return nil
+			}
+			continue
+		case "required":
+			if v.IsZero() {
+				errs.add(path, rule, "is required")
+				return nil
+			}
+			continue
+		case "dive":
+			return validateElements(path, v, rules[i+1:], errs)
+		}
+		value := indirect(v)
+		if !value.IsValid() {
+			return nil
+		}
+		message, err := checkRule(rule, value)
+		if err != nil {
+			return fmt.Errorf("way: field %s: %w", path, err)
+		}
+		if message != "" {
+			errs.add(path, rule, message)
+			return nil
+		}
+	}
+	value := indirect(v)
+	if value.IsValid() && value.Kind() == reflect.Struct && value.Type() != timeType {
+		return validateStruct(path, value, errs)
+	}
+	return nil
+}
+
+// validateElements applies rules to each element of the slice, array or map v.
+func validateElements(path string, v reflect.Value, rules []validationRule, errs *ValidationErrors) error {
+	value := indirect(v)
+	if !value.IsValid() {
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := validateField(fmt.Sprintf("%s[%d]", path, i), value.Index(i), rules, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			if err := validateField(fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), iter.Value(), rules, errs); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("way: field %s: dive requires a slice, array or map, got %s", path, value.Kind())
+	}
+	return nil
+}
+
+// checkRule checks a single rule against the non-pointer value v.
+// It returns a failure message, or an error if the rule is invalid.
+func checkRule(rule validationRule, v reflect.Value) (string, error) {
+	switch rule.name {
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(rule.param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s parameter %q", rule.name, rule.param)
+		}
+		size, unit, err := measure(v)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", rule.name, err)
+		}
+		switch {
+		case rule.name == "min" && size < limit:
+			return fmt.Sprintf("must be at least %s%s", rule.param, unit), nil
+		case rule.name == "max" && size > limit:
+			return fmt.Sprintf("must be at most %s%s", rule.param, unit), nil
+		case rule.name == "len" && size != limit:
+			return fmt.Sprintf("must be exactly %s%s", rule.param, unit), nil
+		}
+		return "", nil
+	case "email":
+		s, err := stringValue(rule, v)
+		if err != nil {
+			return "", err
+		}
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be a valid email address", nil
+		}
+		return "", nil
+	case "url":
+		s, err := stringValue(rule, v)
+		if err != nil {
+			return "", err
+		}
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL", nil
+		}
+		return "", nil
+	case "uuid":
+		s, err := stringValue(rule, v)
+		if err != nil {
+			return "", err
+		}
+		if !uuidPattern.MatchString(s) {
+			return "must be a valid UUID", nil
+		}
+		return "", nil
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(rule.param) {
+			if s == option {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", rule.param), nil
+	case "regexp":
+		s, err := stringValue(rule, v)
+		if err != nil {
+			return "", err
+		}
+		re, err := compileRulePattern(rule.param)
+		if err != nil {
+			return "", err
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("must match pattern %s", rule.param), nil
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown validation rule %q", rule.name)
+	}
+}
+
+// measure returns the size of v used by min, max and len, and the unit used in messages.
+func measure(v reflect.Value) (float64, string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", nil
+	default:
+		return 0, "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// stringValue returns v as a string for rules that only apply to strings.
+func stringValue(rule validationRule, v reflect.Value) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("%s requires a string, got %s", rule.name, v.Type())
+	}
+	return v.String(), nil
+}
+
+// compileRulePattern compiles and caches a regexp rule pattern.
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp %q: %w", pattern, err)
+	}
+	regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+// parseRules parses and caches a validate struct tag.
+func parseRules(tag string) ([]validationRule, error) {
+	if cached, ok := ruleCache.Load(tag); ok {
+		return cached.([]validationRule), nil
+	}
+	var rules []validationRule
+	rest := tag
+	for rest != "" {
+		var part string
+		if strings.HasPrefix(rest, "regexp=") {
+			part, rest = rest, ""
+		} else {
+			part, rest, _ = strings.Cut(rest, ",")
+		}
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		switch name {
+		case "required", "omitempty", "dive", "email", "url", "uuid":
+		case "min", "max", "len", "oneof", "regexp":
+			if param == "" {
+				return nil, fmt.Errorf("validation rule %q requires a parameter", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", name)
+		}
+		rules = append(rules, validationRule{name: name, param: param})
+	}
+	ruleCache.Store(tag, rules)
+	return rules, nil
+}
+
+// add appends a field error for the failed rule.
+func (e *ValidationErrors) add(path string, rule validationRule, message string) {
+	*e = append(*e, FieldError{Field: path, Rule: rule.name, Param: rule.param, Message: message})
+}
+
+// fieldName returns the json name of a struct field, or its Go name when it has no json tag.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// joinFieldPath joins a parent path and a field name.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indirect dereferences pointers and interfaces, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
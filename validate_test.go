@@ -0,0 +1,152 @@
+package way
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	Street string `json:"street" validate:"required"`
+	Zip    string `json:"zip" validate:"regexp=^[0-9]{5}(-[0-9]{4})?$"`
+}
+
+type validateItem struct {
+	SKU      string `json:"sku" validate:"required,len=8"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type validateOrder struct {
+	ID       string            `json:"id" validate:"uuid"`
+	Email    string            `json:"email" validate:"required,email"`
+	Website  string            `json:"website" validate:"omitempty,url"`
+	Status   string            `json:"status" validate:"oneof=pending paid shipped"`
+	Address  *validateAddress  `json:"address" validate:"required"`
+	Items    []validateItem    `json:"items" validate:"required,min=1,dive"`
+	Tags     []string          `json:"tags" validate:"max=3,dive,min=2"`
+	Metadata map[string]string `json:"metadata" validate:"dive,max=5"`
+}
+
+func validOrder() validateOrder {
+	return validateOrder{
+		ID:      "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		Email:   "ada@example.com",
+		Status:  "paid",
+		Address: &validateAddress{Street: "1 Main St", Zip: "12345"},
+		Items:   []validateItem{{SKU: "ABCD1234", Quantity: 2}},
+	}
+}
+
+func TestValidatePassesValidStruct(t *testing.T) {
+	order := validOrder()
+	if err := Validate(&order); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestValidateReportsFieldPaths(t *testing.T) {
+	order := validOrder()
+	order.ID = "not-a-uuid"
+	order.Email = "ada"
+	order.Website = "example.com"
+	order.Status = "lost"
+	order.Address.Street = ""
+	order.Address.Zip = "12,34"
+	order.Items = append(order.Items, validateItem{SKU: "SHORT", Quantity: 0})
+	order.Tags = []string{"ok", "x"}
+	order.Metadata = map[string]string{"note": "too long"}
+
+	err := Validate(order)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want ValidationErrors", err)
+	}
+	got := map[string]string{}
+	for _, fieldErr := range errs {
+		got[fieldErr.Field] = fieldErr.Rule
+	}
+	want := map[string]string{
+		"id":                "uuid",
+		"email":             "email",
+		"website":           "url",
+		"status":            "oneof",
+		"address.street":    "required",
+		"address.zip":       "regexp",
+		"items[1].sku":      "len",
+		"items[1].quantity": "min",
+		"tags[1]":           "min",
+		"metadata[note]":    "max",
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("field %s rule = %q, want %q (all errors: %v)", field, got[field], rule, errs)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+}
+
+func TestValidateRequiredPointer(t *testing.T) {
+	order := validOrder()
+	order.Address = nil
+	order.Items = nil
+
+	err := Validate(&order)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("error = %v, want address and items required", err)
+	}
+}
+
+func TestValidateUnknownRuleIsNotValidationError(t *testing.T) {
+	var v struct {
+		Name string `validate:"required,shiny"`
+	}
+	err := Validate(&v)
+	var errs ValidationErrors
+	if err == nil || errors.As(err, &errs) {
+		t.Fatalf("error = %v, want tag error", err)
+	}
+}
+
+func TestBindAndValidateRendersUnprocessableEntity(t *testing.T) {
+	w := New()
+	w.POST("/orders", E(func(c *Context) error {
+		var order validateOrder
+		if err := c.BindAndValidate(&order); err != nil {
+			return err
+		}
+		c.Status(http.StatusCreated)
+		return nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"email":"nope","status":"paid","items":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	var body struct {
+		Code   string       `json:"code"`
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body.Code != "validation_failed" || len(body.Errors) == 0 {
+		t.Fatalf("body = %s, want validation errors", rec.Body.String())
+	}
+	fields := map[string]bool{}
+	for _, fieldErr := range body.Errors {
+		fields[fieldErr.Field] = true
+	}
+	if !fields["email"] || !fields["items"] || !fields["address"] {
+		t.Fatalf("errors = %+v, want email, items and address", body.Errors)
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/swayedev/way/database"
+	"github.com/swayedev/way/trace"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/securecookie"
@@ -30,10 +33,26 @@ type Way struct {
 	Server *http.Server
 	// Listener is the network listener.
 	Listener net.Listener
-	// Logger is the logger.
+	// Logger is the logger. It writes to the structured logger returned by Slog unless replaced.
 	Logger *log.Logger
+	// slogger is the structured logger set with SetSlog.
+	slogger *slog.Logger
+	// slogBridge is the Logger created for slogger, used to detect a replaced Logger.
+	slogBridge *log.Logger
 	// HTTPClient is used by context helpers that make outbound HTTP requests.
 	HTTPClient *http.Client
+	// errorHandler handles errors passed to Context.Error.
+	errorHandler ErrorHandler
+	// bindOptions configures the Context bind helpers.
+	bindOptions BindOptions
+	// tracer creates request, database and outbound request spans when set.
+	tracer trace.Tracer
+	// pre is the middleware added with Pre, which runs before routing.
+	pre []MiddlewareFunc
+	// trustedProxies are the proxy address ranges whose forwarding headers are honoured.
+	trustedProxies []netip.Prefix
+	// renderers are the renderers added with RegisterRenderer, used by Context.Negotiate.
+	renderers []mediaRenderer
 }
 
 // HandlerFunc is a function type that represents a handler for a request.
@@ -41,6 +60,11 @@ type Way struct {
 // and allows the handler to generate a response.
 type HandlerFunc func(*Context)
 
+// HandlerFuncE is a handler that returns an error.
+// A non-nil error is passed to the error handler set with SetErrorHandler,
+// so handlers do not need to write error responses themselves. Register it with E.
+type HandlerFuncE func(*Context) error
+
 // MiddlewareFunc represents a function that takes a HandlerFunc and returns a modified HandlerFunc.
 type MiddlewareFunc func(HandlerFunc) HandlerFunc
 
@@ -58,18 +82,23 @@ func New() *Way {
 		WriteTimeout:      15 * time.Second,
 		IdleTimeout:       30 * time.Second,
 	}
-	return &Way{
+	w := &Way{
 		router:     mux.NewRouter(),
 		Server:     server,
 		sessions:   sessions,
-		Logger:     defaultLogger(),
 		HTTPClient: defaultHTTPClient(),
 	}
+	w.SetSlog(defaultSlogger())
+	return w
 }
 
 // SetLogger sets the logger.
+// Structured log records from Slog are written through it as text lines,
+// filtered by the WAY_LOG_LEVEL environment variable. Prefer SetSlog for new code.
 func (w *Way) SetLogger(logger *log.Logger) {
 	w.Logger = logger
+	w.slogger = nil
+	w.slogBridge = nil
 }
 
 // SetRouter sets the HTTP router.
@@ -104,6 +133,12 @@ func (w *Way) Log() *log.Logger {
 	return defaultLogger()
 }
 
+// SetErrorHandler sets the handler for errors returned by HandlerFuncE handlers
+// and passed to Context.Error. A nil handler restores DefaultErrorHandler.
+func (w *Way) SetErrorHandler(handler ErrorHandler) {
+	w.errorHandler = handler
+}
+
 // SetDB sets the database connection for the Way object.
 // It takes a pointer to a DB object as a parameter and assigns it to the db field of the Way object.
 func (w *Way) SetDB(db *DB) {
@@ -150,56 +185,153 @@ func (w *Way) SetSession(s *Session) {
 }
 
 // Use adds a middleware to the middleware stack.
+// Middleware runs in this order: global middleware added with Use, group middleware,
+// route middleware passed to GET/POST/..., then the handler.
 func (w *Way) Use(middleware ...MiddlewareFunc) {
-	w.router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
-			ctx := newContextWithHTTPClient(wr, r, w.db, w.sessions, w.Logger, w.HTTPClient)
+	w.use(w.router, middleware...)
+}
 
-			handler := func(c *Context) {
-				next.ServeHTTP(wr, r)
-			}
+// Pre adds middleware that runs before the router matches a route, for every request
+// including those that match no route or method. Use it for middleware such as CORS that
+// must answer OPTIONS preflights for routes registered only with GET or POST.
+// Pre middleware runs before middleware added with Use and cannot see route variables.
+func (w *Way) Pre(middleware ...MiddlewareFunc) {
+	w.pre = append(w.pre, middleware...)
+}
 
-			for i := len(middleware) - 1; i >= 0; i-- {
-				handler = middleware[i](handler)
-			}
+// preRouting runs the middleware added with Pre before next.
+func (w *Way) preRouting(next http.Handler) http.Handler {
+	if len(w.pre) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+		handler := chain(func(c *Context) {
+			next.ServeHTTP(c.Response, c.Request)
+		}, w.pre)
+		handler(w.acquireContext(wr, r))
+	})
+}
+
+// use installs middleware on the given router.
+func (w *Way) use(router *mux.Router, middleware ...MiddlewareFunc) {
+	if len(middleware) == 0 {
+		return
+	}
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
+			ctx := w.acquireContext(wr, r)
+
+			handler := chain(func(c *Context) {
+				next.ServeHTTP(c.Response, c.Request)
+			}, middleware)
 
 			handler(ctx)
 		})
 	})
 }
 
+// chain wraps handler with middleware so that the first middleware is the outermost.
+func chain(handler HandlerFunc, middleware []MiddlewareFunc) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// acquireContext returns the Context for the request.
+// The first call for a request creates the Context and stores it in the request context,
+// later calls from middleware and the route handler reuse it so that values set with
+// Context.Set are visible to the whole chain.
+func (w *Way) acquireContext(wr http.ResponseWriter, r *http.Request) *Context {
+	if ctx := contextFromRequest(r); ctx != nil {
+		ctx.Response = wr
+		ctx.Request = r
+		return ctx
+	}
+	writer, ok := wr.(*responseWriter)
+	if !ok {
+		writer = newResponseWriter(wr)
+	}
+	ctx := newContextWithHTTPClient(writer, r, w.db, w.sessions, w.Logger, w.HTTPClient)
+	ctx.way = w
+	ctx.slogger = w.Slog()
+	ctx.writer = writer
+	ctx.Request = r.WithContext(context.WithValue(r.Context(), contextKey{}, ctx))
+	return ctx
+}
+
 // adaptHandler adapts a HandlerFunc to http.HandlerFunc.
-func adaptHandler(db *DB, s *Session, l *log.Logger, client *http.Client, handler HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := newContextWithHTTPClient(w, r, db, s, l, client)
-		handler(ctx)
+func (w *Way) adaptHandler(handler HandlerFunc) http.HandlerFunc {
+	return func(wr http.ResponseWriter, r *http.Request) {
+		c := w.acquireContext(wr, r)
+		defer func() {
+			if c.sse != nil {
+				c.sse.Close()
+			}
+		}()
+		handler(c)
+	}
+}
+
+// E adapts a HandlerFuncE to a HandlerFunc so that it can be registered as a route.
+// A non-nil error returned by handler is passed to Context.Error.
+//
+//	w.GET("/users/{id}", way.E(func(c *way.Context) error { ... }))
+func E(handler HandlerFuncE) HandlerFunc {
+	return func(c *Context) {
+		if err := handler(c); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// handle registers a new route on the given router.
+// fullPath is only used for logging so that group routes show their complete path.
+// Route middleware wraps the handler in the order given and runs after global and group middleware.
+func (w *Way) handle(router *mux.Router, fullPath, path string, handler HandlerFunc, middleware []MiddlewareFunc, methods ...string) {
+	w.Slog().Debug("registering route", "path", fullPath, "methods", methods)
+	route := router.HandleFunc(path, w.adaptHandler(chain(handler, middleware)))
+	if len(methods) > 0 {
+		route.Methods(methods...)
 	}
 }
 
 // handleFuncWithMethod registers a new route with a matcher for the URL path and the HTTP method.
-func (w *Way) handleFuncWithMethod(path string, handler HandlerFunc, method string) {
-	w.Log().Printf("Registering route %s", path)
-	w.router.HandleFunc(path, adaptHandler(w.db, w.sessions, w.Logger, w.HTTPClient, handler)).Methods(method)
+func (w *Way) handleFuncWithMethod(path string, handler HandlerFunc, middleware []MiddlewareFunc, method string) {
+	w.handle(w.router, path, path, handler, middleware, method)
 }
 
 // HandleFunc registers a new route with a matcher for the URL path.
-func (w *Way) HandleFunc(path string, handler HandlerFunc) {
-	w.Log().Printf("Registering route %s", path)
-	w.router.HandleFunc(path, adaptHandler(w.db, w.sessions, w.Logger, w.HTTPClient, handler))
+// Wrap a HandlerFuncE with E to register it.
+// Optional middleware only runs for this route, after any global middleware added with Use.
+func (w *Way) HandleFunc(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handle(w.router, path, path, handler, middleware)
 }
 
 // HTTP method shortcuts
-func (w *Way) GET(path string, handler HandlerFunc)  { w.handleFuncWithMethod(path, handler, "GET") }
-func (w *Way) POST(path string, handler HandlerFunc) { w.handleFuncWithMethod(path, handler, "POST") }
-func (w *Way) PUT(path string, handler HandlerFunc)  { w.handleFuncWithMethod(path, handler, "PUT") }
-func (w *Way) DELETE(path string, handler HandlerFunc) {
-	w.handleFuncWithMethod(path, handler, "DELETE")
+// Each shortcut accepts a HandlerFunc, or a HandlerFuncE wrapped with E, plus optional route middleware
+// which runs after global middleware in the order given and shares the handler's *Context.
+func (w *Way) GET(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "GET")
+}
+func (w *Way) POST(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "POST")
+}
+func (w *Way) PUT(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "PUT")
+}
+func (w *Way) DELETE(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "DELETE")
 }
-func (w *Way) PATCH(path string, handler HandlerFunc) { w.handleFuncWithMethod(path, handler, "PATCH") }
-func (w *Way) OPTIONS(path string, handler HandlerFunc) {
-	w.handleFuncWithMethod(path, handler, "OPTIONS")
+func (w *Way) PATCH(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "PATCH")
+}
+func (w *Way) OPTIONS(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "OPTIONS")
+}
+func (w *Way) HEAD(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	w.handleFuncWithMethod(path, handler, middleware, "HEAD")
 }
-func (w *Way) HEAD(path string, handler HandlerFunc) { w.handleFuncWithMethod(path, handler, "HEAD") }
 
 // newListener creates a new net.Listener.
 func newListener(network, address string) (net.Listener, error) {
@@ -216,8 +348,8 @@ func (w *Way) Start(address string) error {
 	if err != nil {
 		return err
 	}
-	w.Server.Handler = loggingMiddleware(w.Log(), w.router)
-	w.Log().Printf("Server started at %s", address)
+	w.Server.Handler = w
+	w.Slog().Info("server started", "address", w.Listener.Addr().String())
 	if GetEnv("WAY_LOG_ASCII_ART", "") == "true" {
 		asciiArt := `
 	__        ______   __
@@ -233,25 +365,25 @@ func (w *Way) Start(address string) error {
 
 // Close immediately stops the server.
 func (w *Way) Close() error {
-	w.Log().Println("Server stopping...")
+	w.Slog().Info("server stopping")
 	w.startupMutex.Lock()
 	defer w.startupMutex.Unlock()
-	w.Log().Println("Server stopped")
+	w.Slog().Info("server stopped")
 	return w.Server.Close()
 }
 
 // Shutdown stops the server gracefully.
 func (w *Way) Shutdown(ctx context.Context) error {
-	w.Log().Println("Server stopping gracefully...")
+	w.Slog().Info("server stopping gracefully")
 	w.startupMutex.Lock()
 	defer w.startupMutex.Unlock()
-	w.Log().Println("Server stopped gracefully")
+	w.Slog().Info("server stopped gracefully")
 	return w.Server.Shutdown(ctx)
 }
 
 // Db returns the database instance.
 func (w *Way) Db() *DB {
-	w.Log().Println("Database instance returned")
+	w.Slog().Debug("database instance returned")
 	return w.db
 }
 
@@ -281,24 +413,40 @@ const (
 	envCookieName              = "WAY_DEFAULT_COOKIE_NAME"
 	envCookieEncryptionKey     = "WAY_DEFAULT_COOKIE_ENCRYPTION_KEY"
 	envCookieAuthenticationKey = "WAY_DEFAULT_COOKIE_AUTHENTICATION_KEY"
-	// Environment variables for DefaultLogger
+	// Environment variables for logging
 	envDefaultLogger = "WAY_DEFAULT_LOGGER"
+	envLogLevel      = "WAY_LOG_LEVEL"
+	envLogFormat     = "WAY_LOG_FORMAT"
 )
 
-// defaultLogger returns a new logger that writes to os.Stdout.
+// defaultLogger returns a new logger that writes to the default structured log handler at info level.
 func defaultLogger() *log.Logger {
-	return log.New(os.Stdout, GetEnv(envDefaultLogger, "WAY_INFO")+": ", log.LstdFlags)
+	return slog.NewLogLogger(defaultSlogHandler(os.Stdout), slog.LevelInfo)
 }
 
 func defaultHTTPClient() *http.Client {
 	return &http.Client{Timeout: 15 * time.Second}
 }
 
-func loggingMiddleware(logger *log.Logger, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// ServeHTTP dispatches the request to the router, logging it and starting a
+// server span when a tracer is set. Start serves requests through it, and it can be
+// used to mount Way in another server or in tests.
+func (w *Way) ServeHTTP(wr http.ResponseWriter, r *http.Request) {
+	w.loggingMiddleware(w.traceRequest(w.preRouting(w.router))).ServeHTTP(wr, r)
+}
+
+// loggingMiddleware logs every request served by the router, including unmatched ones.
+// It creates the request Context up front so that the request ID set by middleware is logged.
+func (w *Way) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		logger.Printf("method=%s path=%s duration=%s", r.Method, r.URL.Path, time.Since(start))
+		ctx := w.acquireContext(wr, r)
+		next.ServeHTTP(ctx.Response, ctx.Request)
+		ctx.Slog().Info("request",
+			slog.Int("status", accessLogStatus(ctx)),
+			slog.Int64("bytes", ctx.BytesWritten()),
+			slog.Duration("duration", time.Since(start)),
+		)
 	})
 }
 
@@ -75,7 +75,7 @@ func TestSetHTTPClientNilResetsDefault(t *testing.T) {
 }
 
 func TestHTTPMethodHelpersRegisterRoutes(t *testing.T) {
-	methods := map[string]func(*Way, string, HandlerFunc){
+	methods := map[string]func(*Way, string, HandlerFunc, ...MiddlewareFunc){
 		http.MethodGet:     (*Way).GET,
 		http.MethodPost:    (*Way).POST,
 		http.MethodPut:     (*Way).PUT,
@@ -0,0 +1,91 @@
+package way
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordMiddleware returns middleware that appends name to calls before calling next.
+func recordMiddleware(calls *[]string, name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			*calls = append(*calls, name)
+			next(c)
+		}
+	}
+}
+
+func TestRouteMiddlewareRunsAfterGlobalMiddleware(t *testing.T) {
+	w := New()
+	var calls []string
+	w.Use(recordMiddleware(&calls, "global"))
+	w.GET("/secure", func(c *Context) {
+		calls = append(calls, "handler")
+		c.Status(http.StatusOK)
+	}, recordMiddleware(&calls, "auth"), recordMiddleware(&calls, "audit"))
+
+	req := httptest.NewRequest(http.MethodGet, "/secure", nil)
+	w.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := strings.Join(calls, ","); got != "global,auth,audit,handler" {
+		t.Fatalf("calls = %q, want global,auth,audit,handler", got)
+	}
+}
+
+func TestRouteMiddlewareOnlyAppliesToItsRoute(t *testing.T) {
+	w := New()
+	var calls []string
+	w.GET("/open", func(c *Context) {
+		calls = append(calls, "open")
+	})
+	w.POST("/closed", func(c *Context) {
+		calls = append(calls, "closed")
+	}, recordMiddleware(&calls, "auth"))
+
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/open", nil))
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/closed", nil))
+
+	if got := strings.Join(calls, ","); got != "open,auth,closed" {
+		t.Fatalf("calls = %q, want open,auth,closed", got)
+	}
+}
+
+func TestRouteMiddlewareCanShortCircuit(t *testing.T) {
+	w := New()
+	called := false
+	deny := func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			c.Status(http.StatusUnauthorized)
+		}
+	}
+	w.HandleFunc("/admin", func(c *Context) {
+		called = true
+	}, deny)
+
+	rec := httptest.NewRecorder()
+	w.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if called {
+		t.Fatal("handler called after middleware short-circuited")
+	}
+}
+
+func TestGroupRouteMiddlewareRunsAfterGroupMiddleware(t *testing.T) {
+	w := New()
+	var calls []string
+	g := w.Group("/api", recordMiddleware(&calls, "group"))
+	g.PUT("/items/{id}", func(c *Context) {
+		calls = append(calls, "handler")
+	}, recordMiddleware(&calls, "route"))
+
+	w.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/items/1", nil))
+
+	if got := strings.Join(calls, ","); got != "group,route,handler" {
+		t.Fatalf("calls = %q, want group,route,handler", got)
+	}
+}